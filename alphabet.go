@@ -0,0 +1,214 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"math"
+	"sort"
+)
+
+// ByteFreq is a byte and the number of its occurrences.
+type ByteFreq struct {
+	Byte  byte
+	Count int
+}
+
+// BigramFreq is a pair of subsequent bytes and the number of its occurrences.
+type BigramFreq struct {
+	Bigram [2]byte
+	Count  int
+}
+
+// AlphabetStats describes the alphabet of a haystack.
+type AlphabetStats struct {
+	Histogram     [256]int     // occurrences per byte
+	Total         int          // number of bytes analyzed
+	Size          int          // number of distinct bytes
+	EffectiveSize int          // number of bytes with a share of at least 1/1000
+	Entropy       float64      // Shannon entropy in bits per byte
+	Perplexity    float64      // 2^Entropy
+	TopBytes      []ByteFreq   // most common bytes, descending
+	TopBigrams    []BigramFreq // most common bigrams, descending
+}
+
+// number of entries in TopBytes & TopBigrams
+const topN = 10
+
+// AnalyzeAlphabet returns the byte histogram, alphabet size, entropy and the most
+// common bytes and bigrams of haystack.
+func AnalyzeAlphabet(haystack []byte) *AlphabetStats {
+	return sampleAlphabet(haystack, len(haystack), make([]int, 1<<16))
+}
+
+// SampleAlphabet is AnalyzeAlphabet on a sample of about size bytes
+// taken from 16 evenly spaced chunks of haystack.
+func SampleAlphabet(haystack []byte, size int) *AlphabetStats {
+	return sampleAlphabet(haystack, size, make([]int, 1<<16))
+}
+
+// sampleHistogram is SampleAlphabet without TopBytes and TopBigrams,
+// cheap enough for the Auto profile to run on every search
+func sampleHistogram(haystack []byte, size int) *AlphabetStats {
+	return sampleAlphabet(haystack, size, nil)
+}
+
+// sampleAlphabet analyzes a sample of about size bytes of haystack;
+// the bigrams and the top lists only if bigrams != nil
+func sampleAlphabet(haystack []byte, size int, bigrams []int) *AlphabetStats {
+	const chunks = 16
+	s := &AlphabetStats{}
+	if size < chunks || len(haystack) <= size {
+		s.add(haystack, bigrams)
+	} else {
+		chunk := size / chunks
+		step := len(haystack) / chunks
+		for i := 0; i < chunks; i++ {
+			s.add(haystack[i*step:i*step+chunk], bigrams)
+		}
+	}
+	s.summarize()
+	if bigrams != nil {
+		s.top(bigrams)
+	}
+	return s
+}
+
+func (s *AlphabetStats) add(hay []byte, bigrams []int) {
+	s.Total += len(hay)
+	if bigrams == nil {
+		for _, c := range hay {
+			s.Histogram[c]++
+		}
+		return
+	}
+	for i, c := range hay {
+		s.Histogram[c]++
+		if i > 0 {
+			bigrams[uint16(hay[i-1])<<8|uint16(c)]++
+		}
+	}
+}
+
+// summarize derives the alphabet size and entropy from the histogram
+func (s *AlphabetStats) summarize() {
+	if s.Total == 0 {
+		return
+	}
+	n := float64(s.Total)
+	for _, k := range s.Histogram {
+		if k == 0 {
+			continue
+		}
+		s.Size++
+		if k*1000 >= s.Total {
+			s.EffectiveSize++
+		}
+		p := float64(k) / n
+		s.Entropy -= p * math.Log2(p)
+	}
+	s.Perplexity = math.Exp2(s.Entropy)
+}
+
+// top fills in the most common bytes and bigrams
+func (s *AlphabetStats) top(bigrams []int) {
+	for c, k := range s.Histogram {
+		if k > 0 {
+			s.TopBytes = append(s.TopBytes, ByteFreq{byte(c), k})
+		}
+	}
+	sort.Slice(s.TopBytes, func(i, j int) bool {
+		return s.TopBytes[i].Count > s.TopBytes[j].Count ||
+			(s.TopBytes[i].Count == s.TopBytes[j].Count && s.TopBytes[i].Byte < s.TopBytes[j].Byte)
+	})
+	if len(s.TopBytes) > topN {
+		s.TopBytes = s.TopBytes[:topN]
+	}
+
+	for bg, k := range bigrams {
+		if k == 0 {
+			continue
+		}
+		l := len(s.TopBigrams)
+		if l == topN && k <= s.TopBigrams[l-1].Count {
+			continue
+		}
+		// insert sorted; ties keep the smaller bigram first
+		i := sort.Search(l, func(i int) bool { return s.TopBigrams[i].Count < k })
+		if l < topN {
+			s.TopBigrams = append(s.TopBigrams, BigramFreq{})
+		}
+		copy(s.TopBigrams[i+1:], s.TopBigrams[i:])
+		s.TopBigrams[i] = BigramFreq{[2]byte{byte(bg >> 8), byte(bg)}, k}
+	}
+}
+
+// SmallAlphabet reports whether the haystack is best searched
+// by the algorithms for small alphabets (< 30 letters).
+func (s *AlphabetStats) SmallAlphabet() bool {
+	return s.EffectiveSize < 30
+}
+
+// ProfileFor returns a copy of the built-in profile best suited for a haystack with alphabet s:
+// DNA or Protein for small alphabets, Binary or NaturalText for large ones.
+func ProfileFor(s *AlphabetStats) *Profile {
+	return profileFor(s).clone()
+}
+
+// profileFor returns the built-in profile itself; ProfileFor without the copy
+func profileFor(s *AlphabetStats) *Profile {
+	switch {
+	case s.SmallAlphabet() && s.EffectiveSize <= 5:
+		return dnaProfile
+	case s.SmallAlphabet():
+		return proteinProfile
+	case s.Entropy >= 7:
+		return binaryProfile
+	}
+	return naturalTextProfile
+}
+
+// size of the haystack sample analyzed by the Auto profile
+const autoSampleSize = 1 << 14
+
+// the profile without bands that picks a built-in profile per haystack
+const autoName = "Auto"
+
+// Auto returns the profile that samples the byte histogram of the haystack of each search
+// and uses the built-in profile ProfileFor picks for it. It is used like any other
+// profile - per call, per Matcher or as process default.
+func Auto() *Profile {
+	return &Profile{Name: autoName}
+}
+
+// isAuto reports whether p is the Auto profile (or a copy or JSON round trip of it)
+func (p *Profile) isAuto() bool {
+	return p.Name == autoName && len(p.Bands) == 0
+}
+
+// forHaystack returns the profile to use on haystack
+func (p *Profile) forHaystack(haystack []byte) *Profile {
+	if p.isAuto() {
+		return profileFor(sampleHistogram(haystack, autoSampleSize))
+	}
+	return p
+}
@@ -0,0 +1,170 @@
+// go package alphabet
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * alphabet maps the bytes of haystack and needle to dense codes 0..Size()-1,
+ * i.e. ACGT -> 0..3 or the 20 amino acids -> 0..19.
+ * The search algorithms size their shift & bit tables by Size() and pack
+ * q-grams of codes into Bits() bits each, so that on small alphabets a hash over
+ * a longer q-gram is still free of collisions.
+ * Bytes not in the alphabet share code 0 with the first symbol; algorithms working
+ * on codes therefore verify their candidates on the original bytes.
+ */
+
+package alphabet
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Alphabet maps bytes to dense codes.
+type Alphabet struct {
+	name     string
+	codes    [256]uint8
+	size     int
+	bits     uint
+	identity bool
+}
+
+// Errors
+var (
+	NOSYMBOLS  = errors.New("alphabet has no symbols")
+	TOOMANY    = errors.New("alphabet has more than 256 symbols")
+	AMBIGUOUS  = errors.New("byte is assigned to more than one symbol")
+	EMPTYGROUP = errors.New("alphabet symbol has no bytes")
+)
+
+// Predefined alphabets
+var (
+	// Bytes is the identity on all 256 byte values.
+	Bytes = identity()
+	// DNA maps the nucleotides ACGT (and acgt) to 0..3.
+	DNA = MustNew("DNA", "Aa", "Cc", "Gg", "Tt")
+	// Protein maps the 20 amino acids (one letter code, either case) to 0..19.
+	Protein = MustNew("Protein", "Aa", "Cc", "Dd", "Ee", "Ff", "Gg", "Hh", "Ii", "Kk", "Ll",
+		"Mm", "Nn", "Pp", "Qq", "Rr", "Ss", "Tt", "Vv", "Ww", "Yy")
+)
+
+func identity() *Alphabet {
+	a := &Alphabet{name: "Bytes", size: 256, bits: 8, identity: true}
+	for i := range a.codes {
+		a.codes[i] = uint8(i)
+	}
+	return a
+}
+
+// New returns an alphabet with one code per symbol. A symbol is given as the
+// string of bytes sharing its code, i.e. "Aa" to map upper and lower case A to the same code.
+// Bytes not in any symbol are mapped to code 0.
+func New(name string, symbols ...string) (*Alphabet, error) {
+	switch {
+	case len(symbols) == 0:
+		return nil, NOSYMBOLS
+	case len(symbols) > 256:
+		return nil, TOOMANY
+	}
+	a := &Alphabet{name: name, size: len(symbols)}
+	var assigned [256]bool
+	for code, s := range symbols {
+		if len(s) == 0 {
+			return nil, EMPTYGROUP
+		}
+		for i := 0; i < len(s); i++ {
+			if assigned[s[i]] {
+				return nil, AMBIGUOUS
+			}
+			assigned[s[i]] = true
+			a.codes[s[i]] = uint8(code)
+		}
+	}
+	a.bits = uint(bits.Len(uint(a.size - 1)))
+	if a.bits == 0 {
+		a.bits = 1
+	}
+	a.identity = a.size == 256
+	for i, c := range a.codes {
+		a.identity = a.identity && int(c) == i
+	}
+	return a, nil
+}
+
+// MustNew is New, but panics on error.
+func MustNew(name string, symbols ...string) *Alphabet {
+	a, e := New(name, symbols...)
+	if e != nil {
+		panic("alphabet: " + name + ": " + e.Error())
+	}
+	return a
+}
+
+// Name returns the name of the alphabet.
+func (a *Alphabet) Name() string {
+	return a.name
+}
+
+// Size returns the number of codes.
+func (a *Alphabet) Size() int {
+	return a.size
+}
+
+// Bits returns the number of bits of a code.
+func (a *Alphabet) Bits() uint {
+	return a.bits
+}
+
+// Code returns the code of byte c.
+func (a *Alphabet) Code(c byte) uint8 {
+	return a.codes[c]
+}
+
+// Codes returns the code table indexed by byte.
+func (a *Alphabet) Codes() *[256]uint8 {
+	return &a.codes
+}
+
+// Identity reports whether the alphabet maps every byte to itself.
+func (a *Alphabet) Identity() bool {
+	return a.identity
+}
+
+// Q returns the length of the longest q-gram, not longer than maxQ, whose codes
+// pack into tableBits bits without collisions.
+func (a *Alphabet) Q(tableBits uint, maxQ int) int {
+	q := int(tableBits / a.bits)
+	if q > maxQ {
+		q = maxQ
+	}
+	if q < 1 {
+		q = 1
+	}
+	return q
+}
+
+// Pack returns the codes of the q-gram b packed into len(b)*Bits() bits,
+// the code of b[0] being the most significant.
+func (a *Alphabet) Pack(b []byte) (h uint32) {
+	for _, c := range b {
+		h = h<<a.bits | uint32(a.codes[c])
+	}
+	return h
+}
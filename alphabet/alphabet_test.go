@@ -0,0 +1,124 @@
+// go package alphabet
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package alphabet_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+	bcj "github.com/AndreasBriese/bmatch/bcjsearch"
+	bh2 "github.com/AndreasBriese/bmatch/bh2search"
+	bh "github.com/AndreasBriese/bmatch/bhsearch"
+	bsf "github.com/AndreasBriese/bmatch/bs_fsbndm"
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+func TestNew(t *testing.T) {
+	a, e := alphabet.New("RNA", "Aa", "Cc", "Gg", "Uu")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if a.Size() != 4 || a.Bits() != 2 || a.Identity() {
+		t.Errorf("Size, Bits, Identity = %v, %v, %v; want 4, 2, false", a.Size(), a.Bits(), a.Identity())
+	}
+	for i, c := range []byte("ACGUacgu") {
+		if a.Code(c) != uint8(i%4) {
+			t.Errorf("Code(%q) = %v; want %v", c, a.Code(c), i%4)
+		}
+	}
+	if a.Code('N') != 0 {
+		t.Errorf("Code('N') = %v; want 0", a.Code('N'))
+	}
+	if h := a.Pack([]byte("GAU")); h != 2<<4|0<<2|3 {
+		t.Errorf("Pack(GAU) = %b; want %b", h, 2<<4|3)
+	}
+	if q := a.Q(16, 100); q != 8 {
+		t.Errorf("Q(16, 100) = %v; want 8", q)
+	}
+
+	if !alphabet.Bytes.Identity() || alphabet.Bytes.Size() != 256 || alphabet.Bytes.Bits() != 8 {
+		t.Errorf("Bytes is not the identity on 256 bytes")
+	}
+	if alphabet.Protein.Size() != 20 || alphabet.Protein.Bits() != 5 {
+		t.Errorf("Protein Size, Bits = %v, %v; want 20, 5", alphabet.Protein.Size(), alphabet.Protein.Bits())
+	}
+
+	for _, symbols := range [][]string{{}, {"Aa", "a"}, {"A", ""}} {
+		if _, e := alphabet.New("bad", symbols...); e == nil {
+			t.Errorf("New(%q) accepted", symbols)
+		}
+	}
+}
+
+func referenceFindAll(hay, needle []byte) (found []int) {
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		if bytes.Equal(hay[i:i+len(needle)], needle) {
+			found = append(found, i)
+		}
+	}
+	return found
+}
+
+func TestSearchers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, a := range []*alphabet.Alphabet{alphabet.DNA, alphabet.Protein, alphabet.Bytes} {
+		for _, s := range []registry.Searcher{bsf.WithAlphabet(a), bh.WithAlphabet(a), bcj.WithAlphabet(a), bh2.WithAlphabet(a)} {
+			for k := 0; k < 2000; k++ {
+				// bytes outside the alphabet (N, n) share codes with ACGT
+				hay := make([]byte, 1+rnd.Intn(400))
+				for i := range hay {
+					hay[i] = "ACGTacgtNn"[rnd.Intn(3+rnd.Intn(8))]
+				}
+				m := 3 + rnd.Intn(100)
+				if m > len(hay) {
+					continue
+				}
+				si := rnd.Intn(len(hay) - m + 1)
+				needle := append([]byte{}, hay[si:si+m]...)
+				if k%3 == 0 {
+					needle[rnd.Intn(m)] = 'N'
+				}
+
+				want := referenceFindAll(hay, needle)
+				found, _ := s.FindAll(&hay, &needle)
+				count, _ := s.Count(&hay, &needle)
+				index, _ := s.Index(&hay, &needle)
+				wantIndex := -1
+				if len(want) > 0 {
+					wantIndex = want[0]
+				}
+				if len(found) != len(want) || count != len(want) || index != wantIndex {
+					t.Fatalf("%v %T: %q in %q: FindAll, Count, Index = %v, %v, %v; want %v",
+						a.Name(), s, needle, hay, found, count, index, want)
+				}
+				for i := range want {
+					if found[i] != want[i] {
+						t.Fatalf("%v %T: %q in %q: FindAll = %v; want %v", a.Name(), s, needle, hay, found, want)
+					}
+				}
+			}
+		}
+	}
+}
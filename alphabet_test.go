@@ -0,0 +1,103 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAlphabet_Analyze(t *testing.T) {
+	s := AnalyzeAlphabet([]byte("abababac"))
+	if s.Total != 8 || s.Size != 3 || s.EffectiveSize != 3 {
+		t.Errorf("Total, Size, EffectiveSize = %v, %v, %v; want 8, 3, 3", s.Total, s.Size, s.EffectiveSize)
+	}
+	if s.Histogram['a'] != 4 || s.Histogram['b'] != 3 || s.Histogram['c'] != 1 {
+		t.Errorf("Histogram a, b, c = %v, %v, %v; want 4, 3, 1", s.Histogram['a'], s.Histogram['b'], s.Histogram['c'])
+	}
+	want := -(0.5*math.Log2(0.5) + 0.375*math.Log2(0.375) + 0.125*math.Log2(0.125))
+	if math.Abs(s.Entropy-want) > 1e-9 {
+		t.Errorf("Entropy = %v; want %v", s.Entropy, want)
+	}
+	if s.TopBytes[0] != (ByteFreq{'a', 4}) || s.TopBytes[2] != (ByteFreq{'c', 1}) {
+		t.Errorf("TopBytes = %v", s.TopBytes)
+	}
+	// ab: 3, ba: 3, ac: 1
+	if len(s.TopBigrams) != 3 || s.TopBigrams[0] != (BigramFreq{[2]byte{'a', 'b'}, 3}) ||
+		s.TopBigrams[1] != (BigramFreq{[2]byte{'b', 'a'}, 3}) || s.TopBigrams[2].Count != 1 {
+		t.Errorf("TopBigrams = %v", s.TopBigrams)
+	}
+}
+
+func TestAlphabet_ProfileFor(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	gen := func(letters string) []byte {
+		b := make([]byte, 1<<17)
+		for i := range b {
+			if letters == "" {
+				b[i] = byte(rnd.Intn(256))
+			} else {
+				b[i] = letters[rnd.Intn(len(letters))]
+			}
+		}
+		return b
+	}
+	for _, c := range []struct {
+		hay  []byte
+		want *Profile
+	}{
+		{gen("ACGT"), dnaProfile},
+		{gen("ACDEFGHIKLMNPQRSTVWY"), proteinProfile},
+		{gen(""), binaryProfile},
+		{hay, naturalTextProfile},
+	} {
+		if p := ProfileFor(SampleAlphabet(c.hay, autoSampleSize)); p == c.want || !reflect.DeepEqual(p, c.want) {
+			t.Errorf("ProfileFor = %v; want %v", p.Name, c.want.Name)
+		}
+		// the Auto profile's histogram only sample
+		h, s := sampleHistogram(c.hay, autoSampleSize), SampleAlphabet(c.hay, autoSampleSize)
+		if h.Histogram != s.Histogram || h.EffectiveSize != s.EffectiveSize || h.Entropy != s.Entropy || h.TopBigrams != nil {
+			t.Errorf("%s: histogram sample differs from SampleAlphabet", c.want.Name)
+		}
+	}
+	short := []byte("a short haystack")
+	if n := testing.AllocsPerRun(10, func() { Auto().forHaystack(short) }); n > 1 {
+		t.Errorf("Auto profile: %v allocations per search; want <= 1", n)
+	}
+}
+
+func TestAlphabet_AutoProfile(t *testing.T) {
+	makeRandomPatterns(64)
+	mt, e := NewMatcher(Auto())
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := range pat {
+		want, _ := bytesIndexCount(&hay, &(pat[i]))
+		if c, _ := mt.Count(&hay, &(pat[i])); c != want {
+			t.Fatalf("Count %q = %v; want %v", pat[i], c, want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// go package bcjsearch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * The variant for reduced alphabets indexes the shift table by the alphabet's codes;
+ * bytes sharing a code only shorten the shifts, candidates are verified on the bytes.
+ */
+
+package bcjsearch
+
+import (
+	"bytes"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+)
+
+// Searcher is bcjsearch working on the codes of an alphabet.
+// It implements registry.Searcher.
+type Searcher struct {
+	a *alphabet.Alphabet
+}
+
+// WithAlphabet returns a Searcher over the alphabet a,
+// i.e. WithAlphabet(alphabet.DNA).Count(&genome, &needle).
+func WithAlphabet(a *alphabet.Alphabet) *Searcher {
+	return &Searcher{a}
+}
+
+func (s *Searcher) Index(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 2 {
+		return -1, NEEDLESHORT
+	}
+
+	found, _ := s.search(*haystack, *needle, modeIndex)
+	if len(found) == 0 {
+		return -1, nil
+	}
+	return found[0], nil
+}
+
+func (s *Searcher) Count(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 2 {
+		return -1, NEEDLESHORT
+	}
+
+	_, count := s.search(*haystack, *needle, modeCount)
+	return count, nil
+}
+
+func (s *Searcher) FindAll(haystack, needle *[]byte) (found []int, e error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return found, NEEDLELONG
+	}
+	if len(*needle) < 2 {
+		return found, NEEDLESHORT
+	}
+
+	found, _ = s.search(*haystack, *needle, modeFindAll)
+	return found, nil
+}
+
+const (
+	modeIndex = iota
+	modeCount
+	modeFindAll
+)
+
+func (s *Searcher) search(hay, needle []byte, mode int) (found []int, count int) {
+
+	var (
+		codes  = s.a.Codes()
+		n      = len(hay)
+		m      = len(needle)
+		mm1    = m - 1
+		jmpMap = make([]int, s.a.Size())
+		i      int
+	)
+
+	// preprocessing
+
+	for i = range jmpMap {
+		jmpMap[i] = m
+	}
+	for i = 0; i < m; i++ {
+		jmpMap[codes[needle[i]]] = mm1 - i
+	}
+
+	// search: i is the end of the window, the shift is read from the char behind it
+	for i = mm1; ; {
+		if hay[i] == needle[mm1] && bytes.Equal(hay[i-mm1:i+1], needle) {
+			count++
+			switch mode {
+			case modeIndex:
+				return append(found, i-mm1), count
+			case modeFindAll:
+				found = append(found, i-mm1)
+			}
+		}
+		if i+1 >= n {
+			break
+		}
+		if i += 1 + jmpMap[codes[hay[i+1]]]; i >= n {
+			break
+		}
+	}
+
+	return found, count
+}
@@ -26,9 +26,8 @@ import (
 	"errors"
 )
 
-// Alphabet & Errors
+// Errors
 var (
-	ALPHABET    = 256
 	NEEDLESHORT = errors.New("Length needle is < 2")
 	NEEDLELONG  = errors.New("Length needle > length haystack")
 )
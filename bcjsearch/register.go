@@ -0,0 +1,48 @@
+// go package bcjsearch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bcjsearch
+
+import (
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// Name is the name bcjsearch is registered by.
+const Name = "bcjsearch"
+
+// searcher adapts the package functions to registry.Searcher
+type searcher struct{}
+
+func (searcher) Index(haystack, needle *[]byte) (int, error)     { return Index(haystack, needle) }
+func (searcher) Count(haystack, needle *[]byte) (int, error)     { return Count(haystack, needle) }
+func (searcher) FindAll(haystack, needle *[]byte) ([]int, error) { return FindAll(haystack, needle) }
+
+func init() {
+	// single character shift; was found useful on very long needles over small alphabets
+	registry.MustRegister(registry.Algorithm{
+		Name:      Name,
+		MinNeedle: 2,
+		WorstCase: registry.Quadratic,
+		Alphabet:  registry.SmallAlphabet,
+		Searcher:  searcher{},
+	})
+}
@@ -22,7 +22,12 @@
 
 package bcjsearch
 
-import "runtime"
+import (
+	"bytes"
+	"runtime"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+)
 
 // import (
 // "bytes"
@@ -48,13 +53,13 @@ func findFI(haystack, pattern *[]byte) int {
 		z         = 1 - mm1&1
 		lim       = (m + z) >> 1
 		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
+		jmpMap    = make([]int, alphabet.Bytes.Size())
 		i, j, jmp int
 	)
 
 	// preprocessing
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = m
 	}
 
@@ -62,6 +67,14 @@ func findFI(haystack, pattern *[]byte) int {
 		jmpMap[needle[i]] = mm1 - i
 	}
 
+	// haystack as long as needle: there is no next char to jump from
+	if n == mm1 {
+		if bytes.Equal(hay, needle) {
+			return 0
+		}
+		return -1
+	}
+
 	i = mm1
 
 	for {
@@ -72,6 +85,7 @@ func findFI(haystack, pattern *[]byte) int {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
+				break
 			}
 			if j == lim {
 				return i - mm1
@@ -117,13 +131,13 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 		z         = 1 - mm1&1
 		lim       = (m + z) >> 1
 		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
+		jmpMap    = make([]int, alphabet.Bytes.Size())
 		i, j, jmp int
 	)
 
 	// preprocessing
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = m
 	}
 
@@ -131,6 +145,14 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 		jmpMap[needle[i]] = mm1 - i
 	}
 
+	// haystack as long as needle: there is no next char to jump from
+	if n == mm1 {
+		if bytes.Equal(hay, needle) {
+			found = append(found, 0)
+		}
+		return found
+	}
+
 	i = mm1
 
 	for {
@@ -141,9 +163,10 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
+				break
 			}
 			if j == lim {
-				found = append(found, i)
+				found = append(found, i-mm1)
 			}
 		}
 		for jmp < n {
@@ -168,7 +191,7 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 			}
 		}
 		if j == mm1 {
-			found = append(found, i)
+			found = append(found, i-mm1)
 		}
 	}
 
@@ -186,13 +209,13 @@ func count(haystack, pattern *[]byte) (count int) {
 		z         = 1 - mm1&1
 		lim       = (m + z) >> 1
 		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
+		jmpMap    = make([]int, alphabet.Bytes.Size())
 		i, j, jmp int
 	)
 
 	// preprocessing
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = m
 	}
 
@@ -200,6 +223,14 @@ func count(haystack, pattern *[]byte) (count int) {
 		jmpMap[needle[i]] = mm1 - i
 	}
 
+	// haystack as long as needle: there is no next char to jump from
+	if n == mm1 {
+		if bytes.Equal(hay, needle) {
+			count++
+		}
+		return count
+	}
+
 	i = mm1
 
 	for {
@@ -210,6 +241,7 @@ func count(haystack, pattern *[]byte) (count int) {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
+				break
 			}
 			if j == lim {
 				count++
@@ -269,13 +301,13 @@ func findFI_CC(haystack, pattern *[]byte,
 		z         = 1 - mm1&1
 		lim       = (m + z) >> 1
 		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
+		jmpMap    = make([]int, alphabet.Bytes.Size())
 		i, j, jmp int
 	)
 
 	// preprocessing
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = m
 	}
 
@@ -375,13 +407,13 @@ func findALL_CC(haystack, pattern *[]byte,
 		z         = 1 - mm1&1
 		lim       = (m + z) >> 1
 		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
+		jmpMap    = make([]int, alphabet.Bytes.Size())
 		i, j, jmp int
 	)
 
 	// preprocessing
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = m
 	}
 
@@ -0,0 +1,142 @@
+// go package bh2search
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * The variant for reduced alphabets packs the codes of the 2-gram into the hash
+ * without collisions, sizing the shift table by the alphabet (DNA: 16 entries).
+ */
+
+package bh2search
+
+import (
+	"bytes"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+)
+
+// Searcher is bh2search working on the codes of an alphabet.
+// It implements registry.Searcher.
+type Searcher struct {
+	a *alphabet.Alphabet
+}
+
+// WithAlphabet returns a Searcher over the alphabet a,
+// i.e. WithAlphabet(alphabet.Protein).Count(&proteome, &needle).
+func WithAlphabet(a *alphabet.Alphabet) *Searcher {
+	return &Searcher{a}
+}
+
+func (s *Searcher) Index(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 3 {
+		return -1, NEEDLESHORT
+	}
+
+	found, _ := s.search(*haystack, *needle, modeIndex)
+	if len(found) == 0 {
+		return -1, nil
+	}
+	return found[0], nil
+}
+
+func (s *Searcher) Count(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 3 {
+		return -1, NEEDLESHORT
+	}
+
+	_, count := s.search(*haystack, *needle, modeCount)
+	return count, nil
+}
+
+func (s *Searcher) FindAll(haystack, needle *[]byte) (found []int, e error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return found, NEEDLELONG
+	}
+	if len(*needle) < 3 {
+		return found, NEEDLESHORT
+	}
+
+	found, _ = s.search(*haystack, *needle, modeFindAll)
+	return found, nil
+}
+
+const (
+	modeIndex = iota
+	modeCount
+	modeFindAll
+)
+
+func (s *Searcher) search(hay, needle []byte, mode int) (found []int, count int) {
+
+	var (
+		n      = len(hay)
+		m      = len(needle)
+		mm1    = m - 1
+		jmpMap = make([]int32, 1<<(2*s.a.Bits()))
+		h      uint32
+		i, sh1 int
+	)
+
+	// preprocessing
+
+	for i = range jmpMap {
+		jmpMap[i] = int32(mm1)
+	}
+	for i = 1; i < mm1; i++ {
+		jmpMap[s.a.Pack(needle[i-1:i+1])] = int32(mm1 - i)
+	}
+	// the last 2-gram marks a candidate; after checking it shift by sh1
+	h = s.a.Pack(needle[m-2:])
+	sh1 = int(jmpMap[h])
+	jmpMap[h] = 0
+
+	// search
+	for i = mm1; i < n; {
+		if j := jmpMap[s.a.Pack(hay[i-1:i+1])]; j != 0 {
+			i += int(j)
+			continue
+		}
+		if bytes.Equal(hay[i-mm1:i+1], needle) {
+			count++
+			switch mode {
+			case modeIndex:
+				return append(found, i-mm1), count
+			case modeFindAll:
+				found = append(found, i-mm1)
+			}
+		}
+		i += sh1
+	}
+
+	return found, count
+}
@@ -39,9 +39,8 @@ import (
 	"errors"
 )
 
-// Alphabet & Errors
+// Errors
 var (
-	ALPHABET    = 256
 	NEEDLESHORT = errors.New("Length needle is < 3")
 	NEEDLELONG  = errors.New("Length needle > length haystack")
 )
@@ -0,0 +1,45 @@
+// go package bh2search
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bh2search
+
+import (
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// FuzzSearch compares Index, Count and FindAll with bytes.Index:
+// go test -fuzz FuzzSearch ./bh2search
+func FuzzSearch(f *testing.F) {
+	for _, s := range testcorpus.Seeds() {
+		f.Add(s.Haystack, s.Needle)
+	}
+	f.Fuzz(func(t *testing.T, hay, needle []byte) {
+		if len(needle) < 3 || len(needle) > len(hay) {
+			return
+		}
+		if e := testcorpus.Compare(searcher{}, hay, needle); e != nil {
+			t.Fatalf("haystack %q, needle %q: %v", hay, needle, e)
+		}
+	})
+}
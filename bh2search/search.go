@@ -35,23 +35,27 @@
 
 package bh2search
 
+import (
+	"github.com/AndreasBriese/bmatch/alphabet"
+)
+
 func findFI(haystack, pattern *[]byte) int {
 
 	var (
-		hay       = *haystack
-		needle    = *pattern
-		n         = len(hay) - 1
-		m         = len(needle)
-		mm1       = m - 1
-		lim       = (m + (1 - mm1&1)) >> 1
-		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
-		i, j, jmp int
+		hay    = *haystack
+		needle = *pattern
+		n      = len(hay) - 1
+		m      = len(needle)
+		mm1    = m - 1
+		lim    = (m + (1 - mm1&1)) >> 1
+		lchr   = needle[mm1]
+		jmpMap = make([]int, alphabet.Bytes.Size())
+		i, j   int
 	)
 
 	// preprocessing
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = mm1
 	}
 
@@ -65,48 +69,35 @@ func findFI(haystack, pattern *[]byte) int {
 
 	i = mm1
 
-	for i < n+1 {
-		j = 1
-		for j != 0 {
+	for i <= n {
+		// look for candidate
+		for {
 			// h = hay[i-1] + hay[i]<<2
-			j = jmpMap[uint8(hay[i-1]+hay[i]<<2)]
-			i += j
-			if i < n {
-				continue
+			if j = jmpMap[uint8(hay[i-1]+hay[i]<<2)]; j == 0 {
+				break
 			}
+			if i += j; i > n {
+				break
+			}
+		}
+		if i > n {
 			break
 		}
-		// drive forward
-		jmp = i + 1
 		// check candidate
-		if i < n && 0 == ((hay[i]^needle[mm1])|(hay[i-mm1]^needle[0])) {
+		if 0 == ((hay[i] ^ lchr) | (hay[i-mm1] ^ needle[0])) {
 			// compare frontmost inner & lastmost inner
 			for j = 1; j < lim; j++ {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
-				if hay[i-mm1+j] != needle[j] {
-					jmp = i + j
-				}
 				break
 			}
 			if j == lim {
 				return i - mm1
-				jmp += jmpMap[hay[jmp]]
 			}
 		}
-		i = jmp
-	}
-	// eventually check last character
-	if i == n && 0 == ((hay[i]^lchr)|(hay[i-mm1]^needle[0])) {
-		for j = 1; j < mm1; j++ {
-			if hay[i-mm1+j] != needle[j] {
-				break
-			}
-		}
-		if j == mm1 {
-			return i - mm1
-		}
+		// drive forward
+		i++
 	}
 
 	return -1
@@ -116,15 +107,15 @@ func findFI(haystack, pattern *[]byte) int {
 func findALL(haystack, pattern *[]byte) (found []int) {
 
 	var (
-		hay       = *haystack
-		needle    = *pattern
-		n         = len(hay) - 1
-		m         = len(needle)
-		mm1       = m - 1
-		lim       = (m + (1 - mm1&1)) >> 1
-		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
-		i, j, jmp int
+		hay    = *haystack
+		needle = *pattern
+		n      = len(hay) - 1
+		m      = len(needle)
+		mm1    = m - 1
+		lim    = (m + (1 - mm1&1)) >> 1
+		lchr   = needle[mm1]
+		jmpMap = make([]int, alphabet.Bytes.Size())
+		i, j   int
 	)
 
 	if m < 2 {
@@ -136,7 +127,7 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 	buflen := 100 + (len(hay)/(1+len(needle)))>>8
 	found = make([]int, 0, buflen)
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = mm1
 	}
 
@@ -150,48 +141,35 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 
 	i = mm1
 
-	for i < n+1 {
-		j = 1
-		for j != 0 {
+	for i <= n {
+		// look for candidate
+		for {
 			// h = hay[i-1] + hay[i]<<2
-			j = jmpMap[uint8(hay[i-1]+hay[i]<<2)]
-			i += j
-			if i < n {
-				continue
+			if j = jmpMap[uint8(hay[i-1]+hay[i]<<2)]; j == 0 {
+				break
 			}
+			if i += j; i > n {
+				break
+			}
+		}
+		if i > n {
 			break
 		}
-		// drive forward
-		jmp = i + 1
 		// check candidate
-		if i < n && 0 == ((hay[i]^needle[mm1])|(hay[i-mm1]^needle[0])) {
+		if 0 == ((hay[i] ^ lchr) | (hay[i-mm1] ^ needle[0])) {
 			// compare frontmost inner & lastmost inner
 			for j = 1; j < lim; j++ {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
-				if hay[i-mm1+j] != needle[j] {
-					jmp = i + j
-				}
 				break
 			}
 			if j == lim {
 				found = append(found, i-mm1)
-				jmp += jmpMap[hay[jmp]]
 			}
 		}
-		i = jmp
-	}
-	// eventually check last character
-	if i == n && 0 == ((hay[i]^lchr)|(hay[i-mm1]^needle[0])) {
-		for j = 1; j < mm1; j++ {
-			if hay[i-mm1+j] != needle[j] {
-				break
-			}
-		}
-		if j == mm1 {
-			found = append(found, i-mm1)
-		}
+		// drive forward
+		i++
 	}
 
 	return found
@@ -200,15 +178,15 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 func count(haystack, pattern *[]byte) (count int) {
 
 	var (
-		hay       = *haystack
-		needle    = *pattern
-		n         = len(hay) - 1
-		m         = len(needle)
-		mm1       = m - 1
-		lim       = (m + (1 - mm1&1)) >> 1
-		lchr      = needle[mm1]
-		jmpMap    = make([]int, ALPHABET)
-		i, j, jmp int
+		hay    = *haystack
+		needle = *pattern
+		n      = len(hay) - 1
+		m      = len(needle)
+		mm1    = m - 1
+		lim    = (m + (1 - mm1&1)) >> 1
+		lchr   = needle[mm1]
+		jmpMap = make([]int, alphabet.Bytes.Size())
+		i, j   int
 	)
 
 	if m < 2 {
@@ -217,7 +195,7 @@ func count(haystack, pattern *[]byte) (count int) {
 
 	// preprocessing
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = mm1
 	}
 
@@ -231,46 +209,35 @@ func count(haystack, pattern *[]byte) (count int) {
 
 	i = mm1
 
-	for i < n+1 {
-		j = 1
-		for j != 0 {
+	for i <= n {
+		// look for candidate
+		for {
 			// h = hay[i-1] + hay[i]<<2
-			j = jmpMap[uint8(hay[i-1]+hay[i]<<2)]
-			i += j
-			if i < n {
-				continue
+			if j = jmpMap[uint8(hay[i-1]+hay[i]<<2)]; j == 0 {
+				break
 			}
+			if i += j; i > n {
+				break
+			}
+		}
+		if i > n {
 			break
 		}
-		jmp = i + 1
-		if i < n && 0 == ((hay[i]^needle[mm1])|(hay[i-mm1]^needle[0])) {
+		// check candidate
+		if 0 == ((hay[i] ^ lchr) | (hay[i-mm1] ^ needle[0])) {
 			// compare frontmost inner & lastmost inner
 			for j = 1; j < lim; j++ {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
-				if hay[i-mm1+j] != needle[j] {
-					jmp = i + j
-				}
 				break
 			}
 			if j == lim {
-				jmp += jmpMap[hay[jmp]]
 				count++
 			}
 		}
-		i = jmp
-	}
-
-	if i == n && 0 == ((hay[i]^lchr)|(hay[i-mm1]^needle[0])) {
-		for j = 1; j < mm1; j++ {
-			if hay[i-mm1+j] != needle[j] {
-				break
-			}
-		}
-		if j == mm1 {
-			count++
-		}
+		// drive forward
+		i++
 	}
 
 	return count
@@ -0,0 +1,168 @@
+// go package bhsearch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * 'nos esse quasi nanos gigantum umeris insidentes' (Bernhard von Chartres, 1120)
+ * The giants in this respect:
+ * This is a modification of the Hash3 algorithm published by Lecroc, 2007
+ * LECROQ, T. 2007. Fast exact string matching algorithms. Inf. Process. Lett. 102, 6, 229–235.
+ * consisting of two modifications:
+ *   a different hash function reducing hash by one shift operation
+ *   and a different candidate comparison logic (bitwise computation comparison)
+ *
+ * The variant for reduced alphabets packs the codes of a q-gram into the hash without
+ * collisions, q being as long as the alphabet allows for a 16 bit hash (DNA: q = 8, protein: q = 3).
+ */
+
+package bhsearch
+
+import (
+	"bytes"
+	"math/bits"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+)
+
+// Searcher is the Hash-q algorithm working on the codes of an alphabet.
+// It implements registry.Searcher.
+type Searcher struct {
+	a *alphabet.Alphabet
+}
+
+// WithAlphabet returns a Searcher over the alphabet a,
+// i.e. WithAlphabet(alphabet.DNA).Count(&genome, &needle).
+func WithAlphabet(a *alphabet.Alphabet) *Searcher {
+	return &Searcher{a}
+}
+
+func (s *Searcher) Index(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 3 {
+		return -1, NEEDLESHORT
+	}
+
+	found, _ := s.search(*haystack, *needle, modeIndex)
+	if len(found) == 0 {
+		return -1, nil
+	}
+	return found[0], nil
+}
+
+func (s *Searcher) Count(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 3 {
+		return -1, NEEDLESHORT
+	}
+
+	_, count := s.search(*haystack, *needle, modeCount)
+	return count, nil
+}
+
+func (s *Searcher) FindAll(haystack, needle *[]byte) (found []int, e error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return found, NEEDLELONG
+	}
+	if len(*needle) < 3 {
+		return found, NEEDLESHORT
+	}
+
+	found, _ = s.search(*haystack, *needle, modeFindAll)
+	return found, nil
+}
+
+const (
+	modeIndex = iota
+	modeCount
+	modeFindAll
+)
+
+// hashBits limits the shift table to 1<<hashBits entries
+const hashBits = 16
+
+// qFor returns the q-gram length for a needle of length m over codes of b bits:
+// long enough to make most q-grams of the haystack absent from the needle
+// (and allow shifts of m-q+1), at least 2
+func qFor(m int, b uint) int {
+	q := (bits.Len(uint(m)) + 4) / int(b)
+	if q < 2 {
+		q = 2
+	}
+	if q > m {
+		q = m
+	}
+	return q
+}
+
+func (s *Searcher) search(hay, needle []byte, mode int) (found []int, count int) {
+
+	var (
+		n      = len(hay)
+		m      = len(needle)
+		q      = s.a.Q(hashBits, qFor(m, s.a.Bits()))
+		shift  = make([]int32, 1<<(uint(q)*s.a.Bits()))
+		h      uint32
+		i, sh1 int
+	)
+
+	// preprocessing
+
+	for i = range shift {
+		shift[i] = int32(m - q + 1)
+	}
+	for i = q - 1; i < m-1; i++ {
+		shift[s.a.Pack(needle[i-q+1:i+1])] = int32(m - 1 - i)
+	}
+	// the last q-gram marks a candidate; after checking it shift by sh1
+	h = s.a.Pack(needle[m-q:])
+	sh1 = int(shift[h])
+	shift[h] = 0
+
+	// search
+	for i = m - 1; i < n; {
+		if sh := shift[s.a.Pack(hay[i-q+1:i+1])]; sh != 0 {
+			i += int(sh)
+			continue
+		}
+		if bytes.Equal(hay[i-m+1:i+1], needle) {
+			count++
+			switch mode {
+			case modeIndex:
+				return append(found, i-m+1), count
+			case modeFindAll:
+				found = append(found, i-m+1)
+			}
+		}
+		i += sh1
+	}
+
+	return found, count
+}
@@ -36,9 +36,8 @@ import (
 	"errors"
 )
 
-// Alphabet & Errors
+// Errors
 var (
-	ALPHABET    = 256
 	NEEDLESHORT = errors.New("Length needle is < 3")
 	NEEDLELONG  = errors.New("Length needle > length haystack")
 )
@@ -53,7 +52,7 @@ func Index(haystack, needle *[]byte) (int, error) {
 		return -1, NEEDLESHORT
 	}
 
-	return findFI(haystack, needle), nil
+	return findFI(haystack, needle, table(*needle)), nil
 }
 
 func Count(haystack, needle *[]byte) (int, error) {
@@ -66,7 +65,7 @@ func Count(haystack, needle *[]byte) (int, error) {
 		return -1, NEEDLESHORT
 	}
 
-	return count(haystack, needle), nil
+	return count(haystack, needle, table(*needle)), nil
 }
 
 func FindAll(haystack, needle *[]byte) (found []int, e error) {
@@ -79,5 +78,5 @@ func FindAll(haystack, needle *[]byte) (found []int, e error) {
 		return found, NEEDLESHORT
 	}
 
-	return findALL(haystack, needle), nil
+	return findALL(haystack, needle, table(*needle)), nil
 }
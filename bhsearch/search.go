@@ -32,24 +32,25 @@
 
 package bhsearch
 
-func findFI(haystack, pattern *[]byte) int {
+import (
+	"github.com/AndreasBriese/bmatch/alphabet"
+)
+
+// table returns the shifts for the hashes of the needle's 3-grams; nil for needles < 3
+func table(needle []byte) []int {
 
 	var (
-		hay       = *haystack
-		needle    = *pattern
-		n         = len(hay) - 1
-		m         = len(needle)
-		mm1       = m - 1
-		lim       = (m + (1 - mm1&1)) >> 1
-		lchr      = needle[mm1]
-		h         uint8
-		jmpMap    = make([]int, ALPHABET)
-		i, j, jmp int
+		m      = len(needle)
+		h      uint8
+		jmpMap = make([]int, alphabet.Bytes.Size())
+		i      int
 	)
 
-	// preprocessing
+	if m < 3 {
+		return nil
+	}
 
-	for ; i < ALPHABET; i++ {
+	for ; i < alphabet.Bytes.Size(); i++ {
 		jmpMap[i] = m - 2
 	}
 
@@ -58,193 +59,156 @@ func findFI(haystack, pattern *[]byte) int {
 
 	for i = 3; i < m; i++ {
 		h = needle[i-2] + needle[i-1] + needle[i]<<2
-		jmpMap[h] = mm1 - i
+		jmpMap[h] = m - 1 - i
 	}
 
+	return jmpMap
+}
+
+func findFI(haystack, pattern *[]byte, jmpMap []int) int {
+
+	var (
+		hay    = *haystack
+		needle = *pattern
+		n      = len(hay) - 1
+		m      = len(needle)
+		mm1    = m - 1
+		lim    = (m + (1 - mm1&1)) >> 1
+		lchr   = needle[mm1]
+		h      uint8
+		i, j   int
+	)
+
 	i = mm1
 
-	for i < n+1 {
-		j = 1
+	for i <= n {
 		// look for candidate
-		for j != 0 {
+		for {
 			h = hay[i-2] + hay[i-1] + hay[i]<<2
-			j = jmpMap[h]
-			i += j
-			if i < n {
-				continue
+			if j = jmpMap[h]; j == 0 {
+				break
+			}
+			if i += j; i > n {
+				break
 			}
+		}
+		if i > n {
 			break
 		}
-		// drive forward
-		jmp = i + 1
 		// check candidate
-		if i < n && 0 == ((hay[i]^needle[mm1])|(hay[i-mm1]^needle[0])) {
+		if 0 == ((hay[i] ^ lchr) | (hay[i-mm1] ^ needle[0])) {
 			// compare frontmost inner & lastmost inner
 			for j = 1; j < lim; j++ {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
-				// if hay[i-mm1+j] != needle[j] {
-				// 	jmp = i + j
-				// }
 				break
 			}
 			if j == lim {
 				return i - mm1
 			}
 		}
-		i = jmp
-	}
-	// eventually check last character
-	if i == n && 0 == ((hay[i]^lchr)|(hay[i-mm1]^needle[0])) {
-		for j = 1; j < mm1; j++ {
-			if hay[i-mm1+j] != needle[j] {
-				break
-			}
-		}
-		if j == mm1 {
-			return i - mm1
-		}
+		// drive forward
+		i++
 	}
 
 	return -1
 
 }
 
-func findALL(haystack, pattern *[]byte) (found []int) {
+func findALL(haystack, pattern *[]byte, jmpMap []int) (found []int) {
 
 	var (
-		hay       = *haystack
-		needle    = *pattern
-		n         = len(hay) - 1
-		m         = len(needle)
-		mm1       = m - 1
-		lim       = (m + (1 - mm1&1)) >> 1
-		lchr      = needle[mm1]
-		h         uint8
-		jmpMap    = make([]int, ALPHABET)
-		i, j, jmp int
+		hay    = *haystack
+		needle = *pattern
+		n      = len(hay) - 1
+		m      = len(needle)
+		mm1    = m - 1
+		lim    = (m + (1 - mm1&1)) >> 1
+		lchr   = needle[mm1]
+		h      uint8
+		i, j   int
 	)
 
 	if m < 3 {
 		return found
 	}
 
-	// preprocessing
-
 	buflen := 100 + (len(hay)/(1+len(needle)))>>8
 	found = make([]int, 0, buflen)
 
-	for ; i < ALPHABET; i++ {
-		jmpMap[i] = m - 2
-	}
-
-	h = needle[0] + needle[1] + needle[2]<<2
-	jmpMap[h] = m - 3
-
-	for i = 3; i < m; i++ {
-		h = needle[i-2] + needle[i-1] + needle[i]<<2
-		jmpMap[h] = mm1 - i
-	}
-
 	i = mm1
 
-	for i < n+1 {
-		j = 1
+	for i <= n {
 		// look for candidate
-		for j != 0 {
+		for {
 			h = hay[i-2] + hay[i-1] + hay[i]<<2
-			j = jmpMap[h]
-			i += j
-			if i < n {
-				continue
+			if j = jmpMap[h]; j == 0 {
+				break
+			}
+			if i += j; i > n {
+				break
 			}
+		}
+		if i > n {
 			break
 		}
-		// drive forward
-		jmp = i + 1
 		// check candidate
-		if i < n && 0 == ((hay[i]^needle[mm1])|(hay[i-mm1]^needle[0])) {
+		if 0 == ((hay[i] ^ lchr) | (hay[i-mm1] ^ needle[0])) {
 			// compare frontmost inner & lastmost inner
 			for j = 1; j < lim; j++ {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
 					continue
 				}
-				// if hay[i-mm1+j] != needle[j] {
-				// 	jmp = i + j
-				// }
 				break
 			}
 			if j == lim {
 				found = append(found, i-mm1)
-				jmp += jmpMap[hay[jmp]]
 			}
 		}
-		i = jmp
-	}
-	// eventually check last character
-	if i == n && 0 == ((hay[i]^lchr)|(hay[i-mm1]^needle[0])) {
-		for j = 1; j < mm1; j++ {
-			if hay[i-mm1+j] != needle[j] {
-				break
-			}
-		}
-		if j == mm1 {
-			found = append(found, i-mm1)
-		}
+		// drive forward
+		i++
 	}
 
 	return found
 }
 
-func count(haystack, pattern *[]byte) (count int) {
+func count(haystack, pattern *[]byte, jmpMap []int) (count int) {
 
 	var (
-		hay       = *haystack
-		needle    = *pattern
-		n         = len(hay) - 1
-		m         = len(needle)
-		mm1       = m - 1
-		lim       = (m + (1 - mm1&1)) >> 1
-		lchr      = needle[mm1]
-		h         uint8
-		jmpMap    = make([]int, ALPHABET)
-		i, j, jmp int
+		hay    = *haystack
+		needle = *pattern
+		n      = len(hay) - 1
+		m      = len(needle)
+		mm1    = m - 1
+		lim    = (m + (1 - mm1&1)) >> 1
+		lchr   = needle[mm1]
+		h      uint8
+		i, j   int
 	)
 
 	if m < 3 {
 		return count
 	}
 
-	// preprocessing
-
-	for ; i < ALPHABET; i++ {
-		jmpMap[i] = m - 2
-	}
-
-	h = needle[0] + needle[1] + needle[2]<<2
-	jmpMap[h] = m - 3
-
-	for i = 3; i < m; i++ {
-		h = needle[i-2] + needle[i-1] + needle[i]<<2
-		jmpMap[h] = mm1 - i
-	}
-
 	i = mm1
 
-	for i < n+1 {
-		j = 1
-		for j != 0 {
+	for i <= n {
+		// look for candidate
+		for {
 			h = hay[i-2] + hay[i-1] + hay[i]<<2
-			j = jmpMap[h]
-			i += j
-			if i < n {
-				continue
+			if j = jmpMap[h]; j == 0 {
+				break
+			}
+			if i += j; i > n {
+				break
 			}
+		}
+		if i > n {
 			break
 		}
-		jmp = i + 1
-		if i < n && 0 == ((hay[i]^needle[mm1])|(hay[i-mm1]^needle[0])) {
+		// check candidate
+		if 0 == ((hay[i] ^ lchr) | (hay[i-mm1] ^ needle[0])) {
 			// compare frontmost inner & lastmost inner
 			for j = 1; j < lim; j++ {
 				if 0 == ((hay[i-mm1+j] ^ needle[j]) | (hay[i-j] ^ needle[mm1-j])) {
@@ -253,22 +217,11 @@ func count(haystack, pattern *[]byte) (count int) {
 				break
 			}
 			if j == lim {
-				jmp += jmpMap[hay[jmp]]
 				count++
 			}
 		}
-		i = jmp
-	}
-
-	if i == n && 0 == ((hay[i]^lchr)|(hay[i-mm1]^needle[0])) {
-		for j = 1; j < mm1; j++ {
-			if hay[i-mm1+j] != needle[j] {
-				break
-			}
-		}
-		if j == mm1 {
-			count++
-		}
+		// drive forward
+		i++
 	}
 
 	return count
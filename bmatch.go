@@ -28,11 +28,14 @@
  * 8-10 times faster than Go/Golangs standard string.Index function based on Rabin-Karp-Algorithm
  * with addition-hash.
  * Go's search for one byte in haystack (bytes.Index, referring to an asm routine in sys) is excelled
- * by about 10-20% by the unsafeMEMCHR single byte search functions on 64bit words.
+ * by about 10-20% by the SWAR memchr single byte search functions on 64bit words (swarMEMCHR.go).
  *
- * In case you want to search over small alphabets (<30) you might adjust the switch points between the algorithm.
- * Example: The commented switch-points in the Count() function below were found optimal for 23 amino-acid
- * protein sequences.
+ * The algorithm for a needle is picked by the needle length from a dispatch Profile (see dispatch.go).
+ * The algorithms register themselves in the registry package; an algorithm of your own
+ * registered there may be routed to by a Profile of your own.
+ * In case you want to search over small alphabets (<30) use the built-in profiles DNA or Protein
+ * (switch points found optimal for 23 amino-acid protein sequences) - per call, per Matcher
+ * or as process default.
  */
 
 package bmatch
@@ -40,90 +43,75 @@ package bmatch
 import (
 	"errors"
 
-	// bcj "github.com/AndreasBriese/bmatch/bcjsearch"
-	bh2 "github.com/AndreasBriese/bmatch/bh2search"
-	bh "github.com/AndreasBriese/bmatch/bhsearch"
-	bsf "github.com/AndreasBriese/bmatch/bs_fsbndm"
+	"github.com/AndreasBriese/bmatch/registry"
 )
 
-var ALPHABET = 256
+// Searcher is the interface implemented by all registered algorithms.
+type Searcher = registry.Searcher
+
+// Algorithm describes a registered algorithm and its metadata.
+type Algorithm = registry.Algorithm
+
+// Errors
+var (
+	NEEDLESHORT = errors.New("length of needle is smaller 1")
+)
+
+// Register adds an algorithm of your own to the registry.
+// Once registered it may be named in the dispatch table (see SetDispatch).
+func Register(a Algorithm) error {
+	return registry.Register(a)
+}
+
+// Unregister removes an algorithm of your own from the registry.
+func Unregister(name string) error {
+	return registry.Unregister(name)
+}
+
+// Algorithms returns all registered algorithms sorted by name.
+func Algorithms() []Algorithm {
+	return registry.All()
+}
 
 func Index(haystack, needle *[]byte) (found int, e error) {
 
 	if len(*needle) < 1 {
-		return -1, errors.New("length of needle is smaller 1")
+		return -1, NEEDLESHORT
 	}
 
-	var matchFn func(haystack, needle *[]byte) (int, error)
-	switch {
-	case len(*needle) < 2:
-		return mmIndex(haystack, needle), nil
-	case len(*needle) < 50:
-		matchFn = bsf.Index
-	case len(*needle) < 12000:
-		matchFn = bh.Index
-	case len(*needle) < 350000:
-		matchFn = bh2.Index
-	case len(*needle) < 1<<22:
-		matchFn = bsf.Index
-	default:
-		matchFn = bsf.Index
+	a, e := pick(*haystack, len(*needle))
+	if e != nil {
+		return -1, e
 	}
 
-	return matchFn(haystack, needle)
+	return a.Searcher.Index(haystack, needle)
 }
 
 func FindAll(haystack, needle *[]byte) (found []int, e error) {
 
 	if len(*needle) < 1 {
-		return found, errors.New("length of needle is smaller 1")
+		return found, NEEDLESHORT
 	}
 
-	var matchFn func(haystack, needle *[]byte) ([]int, error)
-	switch {
-	case len(*needle) < 2:
-		return mmFindALL(haystack, needle), nil
-	case len(*needle) < 50:
-		matchFn = bsf.FindAll
-	case len(*needle) < 12000:
-		matchFn = bh.FindAll
-	case len(*needle) < 350000:
-		matchFn = bh2.FindAll
-	case len(*needle) < 1<<22:
-		matchFn = bsf.FindAll
-	default:
-		matchFn = bsf.FindAll
+	a, e := pick(*haystack, len(*needle))
+	if e != nil {
+		return found, e
 	}
 
-	return matchFn(haystack, needle)
+	return a.Searcher.FindAll(haystack, needle)
 
 }
 
 func Count(haystack, needle *[]byte) (found int, e error) {
 
 	if len(*needle) < 1 {
-		return -1, errors.New("length of needle is smaller 1")
+		return -1, NEEDLESHORT
 	}
 
-	var matchFn func(haystack, needle *[]byte) (int, error)
-
-	switch {
-	case len(*needle) < 2:
-		return mmCount(haystack, needle), nil
-	case len(*needle) < 50:
-		//case len(*needle) < 3000: // use for small alphabets
-		matchFn = bsf.Count
-	case len(*needle) < 12000:
-		// case len(*needle) < 16000: // use for small alphabets
-		matchFn = bh.Count
-	case len(*needle) < 350000:
-		matchFn = bh2.Count
-	case len(*needle) < 1<<22:
-		//matchFn = bcj.Count // use for small alphabets
-		matchFn = bsf.Count
-	default:
-		matchFn = bsf.Count
+	a, e := pick(*haystack, len(*needle))
+	if e != nil {
+		return -1, e
 	}
 
-	return matchFn(haystack, needle)
+	return a.Searcher.Count(haystack, needle)
 }
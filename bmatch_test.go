@@ -27,11 +27,11 @@ import (
 	"io/ioutil"
 	"log"
 	"math/rand"
-	"net/http"
 	"os"
 	"strings"
 	"testing"
-	"time"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
 )
 
 const (
@@ -46,7 +46,7 @@ var (
 )
 
 func makeRandomPatterns(lenPatterns int) {
-	rand.Seed(time.Now().UnixNano())
+	rand.Seed(int64(lenPatterns)) // reproducible
 
 	pat = make([][]byte, N+N_NEG) // patterns
 	n := len(hay) - 1             // haystack length -1
@@ -71,31 +71,12 @@ func makeRandomPatterns(lenPatterns int) {
 	}
 }
 
-func downloadWFB() (txt []byte) {
+// loadWFB returns the text of the Factbook if its zip is present (download it from
+// DOWNLOAD_URL to benchmark on it), nil else
+func loadWFB() (txt []byte) {
 
 	if _, err := os.Lstat(WORLDTEXTFILE); err != nil {
-		f, err := os.Create(WORLDTEXTFILE)
-		if err != nil {
-			log.Fatalf("Create %v failed: %v \n", WORLDTEXTFILE, err)
-		}
-		defer f.Close()
-
-		resp, err := http.Get(DOWNLOAD_URL)
-		if err != nil {
-			log.Fatalf("Download from %v failed: %v \n", DOWNLOAD_URL, err)
-		}
-
-		zp, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			log.Fatalf("Reading download failed: %v \n", err)
-		}
-
-		_, err = f.Write(zp)
-		if err != nil {
-			log.Fatalf("Saving to %v failed: %v \n", WORLDTEXTFILE, err)
-		}
-		f.Close()
-
+		return nil
 	}
 
 	zr, err := zip.OpenReader(WORLDTEXTFILE)
@@ -124,20 +105,19 @@ var hay []byte
 
 func TestMain(m *testing.M) {
 
-	hay = downloadWFB()
+	source := WORLDTEXTFILE
+	if hay = loadWFB(); hay == nil {
+		// offline: a megabyte of English-like text
+		source = "testcorpus.Text"
+		hay = testcorpus.Text(1, 1<<20)
+	}
 
 	fmt.Printf("\n###############\nbmatch.go\n")
 	// fmt.Println(string(hay[:1000]))
 
-	alpha := 0
-	for i := 0; i < 256; i++ {
-		needle := []byte{byte(i)}
-		if c := mmIndex(&hay, &needle); c != -1 {
-			alpha++
-		}
-	}
+	alpha := AnalyzeAlphabet(hay)
 
-	fmt.Printf("Haystack: %v loaded (%v bytes)\nAlphabet size: %v\n\n", WORLDTEXTFILE, len(hay), alpha)
+	fmt.Printf("Haystack: %v loaded (%v bytes)\nAlphabet size: %v\n\n", source, len(hay), alpha.Size)
 
 	m.Run()
 
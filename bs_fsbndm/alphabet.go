@@ -0,0 +1,235 @@
+// go package bs_fsbndm
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * 'nos esse quasi nanos gigantum umeris insidentes' (Bernhard von Chartres, 1120)
+ * The giants in this respect:
+ * This is a modification of the Forward Semplified BNDM algorithm published by
+ * S. Faro and T. Lecroq (2008):
+ * Efficient Variants of the Backward-Oracle-Matching Algorithm.
+ * Proceedings of the Prague Stringology Conference 2008, pp.146--160, Czech Technical University in Prague, Czech Republic, (2008).
+ * Lizence of the authors C-implementation: GNU General Public License V.3 as published by the Free Software Foundation
+ *
+ * Modifications for reduced alphabets: the bit table is indexed by the alphabet's codes,
+ * and the first step at the window edge reads a q-gram of codes at once from a precomputed
+ * table - on small alphabets (DNA) that saves most of the candidates the character pair lets pass.
+ */
+
+package bs_fsbndm
+
+import (
+	"bytes"
+	"math/bits"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+)
+
+// Searcher is bs_fsbndm working on the codes of an alphabet.
+// It implements registry.Searcher.
+type Searcher struct {
+	a *alphabet.Alphabet
+}
+
+// WithAlphabet returns a Searcher over the alphabet a,
+// i.e. WithAlphabet(alphabet.DNA).Count(&genome, &needle).
+func WithAlphabet(a *alphabet.Alphabet) *Searcher {
+	return &Searcher{a}
+}
+
+func (s *Searcher) Index(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 2 {
+		return -1, NEEDLESHORT
+	}
+
+	found, _ := s.search(*haystack, *needle, modeIndex)
+	if len(found) == 0 {
+		return -1, nil
+	}
+	return found[0], nil
+}
+
+func (s *Searcher) Count(haystack, needle *[]byte) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return -1, NEEDLELONG
+	}
+	if len(*needle) < 2 {
+		return -1, NEEDLESHORT
+	}
+
+	_, count := s.search(*haystack, *needle, modeCount)
+	return count, nil
+}
+
+func (s *Searcher) FindAll(haystack, needle *[]byte) (found []int, e error) {
+
+	// check length needle
+	if len(*haystack) < len(*needle) {
+		return found, NEEDLELONG
+	}
+	if len(*needle) < 2 {
+		return found, NEEDLESHORT
+	}
+
+	found, _ = s.search(*haystack, *needle, modeFindAll)
+	return found, nil
+}
+
+const (
+	modeIndex = iota
+	modeCount
+	modeFindAll
+)
+
+// qTableBits limits the q-gram table to 1<<qTableBits entries
+const qTableBits = 12
+
+// qFor returns the q-gram length for a needle (suffix) of length p over codes of b bits:
+// long enough to make the q-gram unlikely to appear in the needle by chance,
+// short enough to keep the shift p-q+2 long
+func qFor(p int, b uint) int {
+	q := (bits.Len(uint(p)) + 5) / int(b)
+	if q > p/2 {
+		q = p / 2
+	}
+	return q
+}
+
+func (s *Searcher) search(hay, needle []byte, mode int) (found []int, count int) {
+
+	var (
+		codes            = s.a.Codes()
+		verify           = !s.a.Identity()
+		n                = len(hay)
+		m                = len(needle)
+		p                = m // len Pat
+		longPat          = m > 63
+		bitPat           = make([]uint64, s.a.Size())
+		q                = 2
+		qTab             []uint64
+		bits             uint64
+		i, last, backstp int
+	)
+
+	report := func(idx int) bool {
+		count++
+		if mode == modeFindAll {
+			found = append(found, idx)
+		}
+		if mode == modeIndex {
+			found = append(found, idx)
+			return true
+		}
+		return false
+	}
+
+	// preprocessing
+
+	if longPat {
+		p = 62
+		verify = true
+	}
+
+	for i = range bitPat {
+		bitPat[i] = 1
+	}
+	for i = 0; i < p; i++ {
+		bitPat[codes[needle[m-p+i]]] |= (1 << uint(p-i))
+	}
+
+	// q-gram table on small alphabets: qTab[packed codes of hay[i+2-q:i+2]]
+	// holds the bits after the backward steps down to hay[i+2-q]
+	if q = s.a.Q(qTableBits, qFor(p, s.a.Bits())); q > 2 {
+		var (
+			b    = s.a.Bits()
+			mask = uint32(1)<<b - 1
+		)
+		// codes of hay[i+2-q], .., hay[i], hay[i+1]
+		c := make([]uint32, q)
+		qTab = make([]uint64, 1<<(uint(q)*b))
+	next:
+		for h := range qTab {
+			for k := range c {
+				if c[k] = uint32(h) >> (uint(q-1-k) * b) & mask; int(c[k]) >= len(bitPat) {
+					continue next
+				}
+			}
+			bits = bitPat[c[q-1]] << 1 & bitPat[c[q-2]]
+			for k := q - 3; k >= 0 && bits != 0; k-- {
+				bits = bits << 1 & bitPat[c[k]]
+			}
+			qTab[h] = bits
+		}
+	} else {
+		q = 2
+	}
+
+	// search
+	if bytes.Equal(hay[0:m], needle) && report(0) {
+		return found, count
+	}
+
+	for i = m; i < n-1; {
+		if q > 2 {
+			bits = qTab[s.a.Pack(hay[i+2-q:i+2])]
+			if bits == 0 { // q-gram isn't part of needle -> shift by p-q+2
+				i += p - q + 2
+				continue
+			}
+			backstp = q - 2
+		} else {
+			// check character pair at windows right edge
+			bits = (bitPat[codes[hay[i+1]]] << 1) & bitPat[codes[hay[i]]]
+			if bits == 0 { // bits didn't match with any bytes in needle -> shift by p
+				i += p
+				continue
+			}
+			backstp = 0
+		}
+		// run backwards over the candidate; check & shift
+		last = i
+		for bits != 0 {
+			backstp++
+			bits = (bits << 1) & bitPat[codes[hay[i-backstp]]]
+		}
+		if backstp < p {
+			i += p - backstp
+			continue
+		}
+		if (!verify || bytes.Equal(needle, hay[last-m+1:last+1])) && report(last-m+1) {
+			return found, count
+		}
+		i = last + 1
+	}
+
+	if n > m && bytes.Equal(hay[n-m:], needle) {
+		report(n - m)
+	}
+
+	return found, count
+}
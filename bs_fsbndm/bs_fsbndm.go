@@ -38,9 +38,8 @@ import (
 	"errors"
 )
 
-// Alphabet & Errors
+// Errors
 var (
-	ALPHABET    = 256
 	NEEDLESHORT = errors.New("Length needle is < 2")
 	NEEDLELONG  = errors.New("Length needle > length haystack")
 )
@@ -55,7 +54,7 @@ func Index(haystack, needle *[]byte) (int, error) {
 		return -1, NEEDLESHORT
 	}
 
-	return findFI(haystack, needle), nil
+	return findFI(haystack, needle, table(*needle)), nil
 }
 
 func Count(haystack, needle *[]byte) (int, error) {
@@ -68,7 +67,7 @@ func Count(haystack, needle *[]byte) (int, error) {
 		return -1, NEEDLESHORT
 	}
 
-	return count(haystack, needle), nil
+	return count(haystack, needle, table(*needle)), nil
 }
 
 func FindAll(haystack, needle *[]byte) (found []int, e error) {
@@ -81,5 +80,5 @@ func FindAll(haystack, needle *[]byte) (found []int, e error) {
 		return found, NEEDLESHORT
 	}
 
-	return findALL(haystack, needle), nil
+	return findALL(haystack, needle, table(*needle)), nil
 }
@@ -0,0 +1,46 @@
+// go package bs_fsbndm
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bs_fsbndm
+
+import (
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+func TestCorpora(t *testing.T) {
+	testcorpus.Check(t, searcher{}, 2, 0)
+}
+
+func TestCorpora_Compiled(t *testing.T) {
+	testcorpus.Check(t, testcorpus.Compiling(searcher{}), 2, 0)
+}
+
+func TestCorpora_Alphabets(t *testing.T) {
+	for _, a := range []*alphabet.Alphabet{alphabet.Bytes, alphabet.DNA, alphabet.Protein} {
+		t.Run(a.Name(), func(t *testing.T) {
+			testcorpus.Check(t, WithAlphabet(a), 2, 0)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// go package bs_fsbndm
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * 'nos esse quasi nanos gigantum umeris insidentes' (Bernhard von Chartres, 1120)
+ * The giants in this respect:
+ * This is a modification of the Forward Semplified BNDM algorithm published by
+ * S. Faro and T. Lecroq (2008):
+ * Efficient Variants of the Backward-Oracle-Matching Algorithm.
+ * Proceedings of the Prague Stringology Conference 2008, pp.146--160, Czech Technical University in Prague, Czech Republic, (2008).
+ * Lizence of the authors C-implementation: GNU General Public License V.3 as published by the Free Software Foundation
+ *
+ * Class patterns: each position of the pattern is a set of bytes. FSBNDM handles that naturally,
+ * as the bit table simply holds a position's bit for every byte of its class.
+ */
+
+package bs_fsbndm
+
+// Class is a set of bytes matching at one position of a class pattern.
+type Class [4]uint64
+
+// NewClass returns the class of the bytes b.
+func NewClass(b ...byte) (c Class) {
+	c.Add(b...)
+	return c
+}
+
+// Add adds the bytes b to the class.
+func (c *Class) Add(b ...byte) {
+	for _, x := range b {
+		c[x>>6] |= 1 << (x & 63)
+	}
+}
+
+// Has reports whether b is in the class.
+func (c *Class) Has(b byte) bool {
+	return c[b>>6]&(1<<(b&63)) != 0
+}
+
+// IndexClasses returns the index of the first window of haystack matching pattern or -1.
+func IndexClasses(haystack *[]byte, pattern []Class) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(pattern) {
+		return -1, NEEDLELONG
+	}
+	if len(pattern) < 2 {
+		return -1, NEEDLESHORT
+	}
+
+	found, _ := searchClasses(*haystack, pattern, modeIndex)
+	if len(found) == 0 {
+		return -1, nil
+	}
+	return found[0], nil
+}
+
+// CountClasses returns the number of (overlapping) windows of haystack matching pattern.
+func CountClasses(haystack *[]byte, pattern []Class) (int, error) {
+
+	// check length needle
+	if len(*haystack) < len(pattern) {
+		return -1, NEEDLELONG
+	}
+	if len(pattern) < 2 {
+		return -1, NEEDLESHORT
+	}
+
+	_, count := searchClasses(*haystack, pattern, modeCount)
+	return count, nil
+}
+
+// FindAllClasses returns the indices of all windows of haystack matching pattern.
+func FindAllClasses(haystack *[]byte, pattern []Class) (found []int, e error) {
+
+	// check length needle
+	if len(*haystack) < len(pattern) {
+		return found, NEEDLELONG
+	}
+	if len(pattern) < 2 {
+		return found, NEEDLESHORT
+	}
+
+	found, _ = searchClasses(*haystack, pattern, modeFindAll)
+	return found, nil
+}
+
+// matchClasses reports whether window matches pattern
+func matchClasses(window []byte, pattern []Class) bool {
+	for i := range pattern {
+		if !pattern[i].Has(window[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func searchClasses(hay []byte, pattern []Class, mode int) (found []int, count int) {
+
+	var (
+		n                = len(hay)
+		m                = len(pattern)
+		p                = m // len Pat
+		longPat          = m > 63
+		bitPat           [256]uint64
+		bits             uint64
+		i, last, backstp int
+	)
+
+	report := func(idx int) bool {
+		count++
+		if mode != modeCount {
+			found = append(found, idx)
+		}
+		return mode == modeIndex
+	}
+
+	// preprocessing
+
+	if longPat {
+		p = 62
+	}
+
+	for i = range bitPat {
+		bitPat[i] = 1
+	}
+	for i = 0; i < p; i++ {
+		for c := 0; c < 256; c++ {
+			if pattern[m-p+i].Has(byte(c)) {
+				bitPat[c] |= (1 << uint(p-i))
+			}
+		}
+	}
+
+	// search
+	if matchClasses(hay[0:m], pattern) && report(0) {
+		return found, count
+	}
+
+	for i = m; i < n-1; {
+		// check character pair at windows right edge
+		bits = (bitPat[hay[i+1]] << 1) & bitPat[hay[i]]
+		if bits == 0 { // bits didn't match with any bytes in needle -> shift by p
+			i += p
+			continue
+		}
+		// run backwards over the candidate; check & shift
+		last = i
+		for bits != 0 {
+			backstp++
+			bits = (bits << 1) & bitPat[hay[i-backstp]]
+		}
+		if backstp < p {
+			i += p - backstp
+			backstp = 0
+			continue
+		}
+		backstp = 0
+		if (!longPat || matchClasses(hay[last-m+1:last+1], pattern)) && report(last-m+1) {
+			return found, count
+		}
+		i = last + 1
+	}
+
+	if n > m && matchClasses(hay[n-m:], pattern) {
+		report(n - m)
+	}
+
+	return found, count
+}
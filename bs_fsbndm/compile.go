@@ -0,0 +1,73 @@
+// go package bs_fsbndm
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bs_fsbndm
+
+import (
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// Compiled is a needle with its bit table, built once for many haystacks.
+// It implements registry.Compiled.
+type Compiled struct {
+	needle []byte
+	bitPat []uint64
+}
+
+// Compile builds the bit table of a copy of needle.
+func Compile(needle []byte) (*Compiled, error) {
+	if len(needle) < 2 {
+		return nil, NEEDLESHORT
+	}
+	needle = append([]byte(nil), needle...)
+	return &Compiled{needle, table(needle)}, nil
+}
+
+func (c *Compiled) Index(haystack *[]byte) (int, error) {
+	if len(*haystack) < len(c.needle) {
+		return -1, NEEDLELONG
+	}
+	return findFI(haystack, &c.needle, c.bitPat), nil
+}
+
+func (c *Compiled) Count(haystack *[]byte) (int, error) {
+	if len(*haystack) < len(c.needle) {
+		return -1, NEEDLELONG
+	}
+	return count(haystack, &c.needle, c.bitPat), nil
+}
+
+func (c *Compiled) FindAll(haystack *[]byte) (found []int, e error) {
+	if len(*haystack) < len(c.needle) {
+		return found, NEEDLELONG
+	}
+	return findALL(haystack, &c.needle, c.bitPat), nil
+}
+
+// Compile implements registry.Compiler
+func (searcher) Compile(needle []byte) (registry.Compiled, error) {
+	c, e := Compile(needle)
+	if e != nil {
+		return nil, e
+	}
+	return c, nil
+}
@@ -34,40 +34,55 @@ package bs_fsbndm
 
 import (
 	"bytes"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
 )
 
-func findFI(haystack, pattern *[]byte) int {
+// table returns the bit table of the needle's last p bytes (p = m, 62 for needles over 63 bytes);
+// here comes the magic!!! for each character create a word bloomfilter holding its position(s)!
+func table(needle []byte) []uint64 {
 
 	var (
-		hay                              = *haystack
-		needle                           = *pattern
-		n                                = len(hay)
-		m                                = len(needle)
-		p                                = m // len Pat
-		longPat                          = m > 63
-		bitPat                           = make([]uint64, ALPHABET)
-		bits                             uint64
-		i, lastCharIdx, suffIdx, backstp int
+		m      = len(needle)
+		p      = m // len Pat
+		bitPat = make([]uint64, alphabet.Bytes.Size())
+		i      int
 	)
 
-	// preprocessing
-
-	if longPat {
+	if m > 63 {
 		p = 62
 	}
 
-	for i = 0; i < ALPHABET; i++ {
+	for i = 0; i < alphabet.Bytes.Size(); i++ {
 		bitPat[i] = 1
 	}
 
-	// here comes the magic!!!
-	// for each character create a word bloomfilter holding its position(s)!
 	// for logPat use the last p bytes of needle for pat check & shift
-	suffIdx = m - p
+	suffIdx := m - p
 	for i = 0; i < p; i++ {
 		bitPat[needle[suffIdx+i]] |= (1 << uint(p-i))
 	}
 
+	return bitPat
+}
+
+func findFI(haystack, pattern *[]byte, bitPat []uint64) int {
+
+	var (
+		hay                     = *haystack
+		needle                  = *pattern
+		n                       = len(hay)
+		m                       = len(needle)
+		p                       = m // len Pat
+		longPat                 = m > 63
+		bits                    uint64
+		i, lastCharIdx, backstp int
+	)
+
+	if longPat {
+		p = 62
+	}
+
 	// search
 	if bytes.Equal(hay[0:m], needle) {
 		return 0
@@ -98,6 +113,9 @@ func findFI(haystack, pattern *[]byte) int {
 				i += p
 			}
 		}
+		if bytes.Equal(hay[n-m:], needle) {
+			return n - m
+		}
 		return -1
 	}
 
@@ -130,50 +148,31 @@ func findFI(haystack, pattern *[]byte) int {
 	return -1
 }
 
-func findALL(haystack, pattern *[]byte) (found []int) {
+func findALL(haystack, pattern *[]byte, bitPat []uint64) (found []int) {
 
 	var (
-		hay                              = *haystack
-		needle                           = *pattern
-		n                                = len(hay)
-		m                                = len(needle)
-		p                                = m // len Pat
-		longPat                          = m > 63
-		bitPat                           = make([]uint64, ALPHABET)
-		bits                             uint64
-		i, lastCharIdx, suffIdx, backstp int
+		hay                     = *haystack
+		needle                  = *pattern
+		n                       = len(hay)
+		m                       = len(needle)
+		p                       = m // len Pat
+		longPat                 = m > 63
+		bits                    uint64
+		i, lastCharIdx, backstp int
 	)
 
 	buflen := 100 + (len(hay)/(1+len(needle)))>>8
 	found = make([]int, 0, buflen)
 
-	// preprocessing
-
 	if longPat {
 		p = 62
 	}
 
-	for i = 0; i < ALPHABET; i++ {
-		bitPat[i] = 1
-	}
-
-	// here comes the magic!!!
-	// for each character create a word bloomfilter holding its position(s)!
-	// for logPat use the last p bytes of needle for pat check & shift
-	suffIdx = m - p
-	for i = 0; i < p; i++ {
-		bitPat[needle[suffIdx+i]] |= (1 << uint(p-i))
-	}
-
 	// search
 	if bytes.Equal(hay[0:m], needle) {
 		found = append(found, 0)
 	}
 
-	if bytes.Equal(hay[n-m:], needle) {
-		found = append(found, n-m)
-	}
-
 	if longPat { // search for the suffix length p of m
 		for i = m; i < n-1; {
 			// check character pair at windows right edge
@@ -192,7 +191,6 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 				if i == lastCharIdx {
 					if bytes.Equal(needle, hay[lastCharIdx-m+1:lastCharIdx+1]) {
 						found = append(found, lastCharIdx-m+1)
-						i = lastCharIdx + 1
 					}
 					i++
 				}
@@ -201,6 +199,11 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 			}
 		}
 
+		// the window at the haystack's end comes last
+		if n > m && bytes.Equal(hay[n-m:], needle) {
+			found = append(found, n-m)
+		}
+
 		return found
 	}
 
@@ -227,48 +230,38 @@ func findALL(haystack, pattern *[]byte) (found []int) {
 		}
 	}
 
+	// the window at the haystack's end comes last
+	if n > m && bytes.Equal(hay[n-m:], needle) {
+		found = append(found, n-m)
+	}
+
 	return found
 
 }
 
-func count(haystack, pattern *[]byte) (count int) {
+func count(haystack, pattern *[]byte, bitPat []uint64) (count int) {
 
 	var (
-		hay                              = *haystack
-		needle                           = *pattern
-		n                                = len(hay)
-		m                                = len(needle)
-		p                                = m // len Pat
-		longPat                          = m > 63
-		bitPat                           = make([]uint64, ALPHABET)
-		bits                             uint64
-		i, lastCharIdx, suffIdx, backstp int
+		hay                     = *haystack
+		needle                  = *pattern
+		n                       = len(hay)
+		m                       = len(needle)
+		p                       = m // len Pat
+		longPat                 = m > 63
+		bits                    uint64
+		i, lastCharIdx, backstp int
 	)
 
-	// preprocessing
-
 	if longPat {
 		p = 62
 	}
 
-	for i = 0; i < ALPHABET; i++ {
-		bitPat[i] = 1
-	}
-
-	// here comes the magic!!!
-	// for each character create a word bloomfilter holding its position(s)!
-	// for logPat use the last p bytes of needle for pat check & shift
-	suffIdx = m - p
-	for i = 0; i < p; i++ {
-		bitPat[needle[suffIdx+i]] |= (1 << uint(p-i))
-	}
-
 	// search
 	if bytes.Equal(hay[0:m], needle) {
 		count++
 	}
 
-	if bytes.Equal(hay[n-m:], needle) {
+	if n > m && bytes.Equal(hay[n-m:], needle) {
 		count++
 	}
 
@@ -290,7 +283,6 @@ func count(haystack, pattern *[]byte) (count int) {
 				if i == lastCharIdx {
 					if bytes.Equal(needle, hay[lastCharIdx-m+1:lastCharIdx+1]) {
 						count++
-						i = lastCharIdx + 1
 					}
 					i++
 				}
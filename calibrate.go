@@ -0,0 +1,359 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/AndreasBriese/bmatch/internal/needles"
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// CalibrateOptions control Calibrate. The zero value is usable.
+type CalibrateOptions struct {
+	Name       string        // name of the resulting profile; default "calibrated"
+	Lengths    []int         // needle lengths to time; default 1, 2, 3, 4, 6, 8, 12, 16, .. up to MaxNeedle
+	MaxNeedle  int           // longest needle timed by default; default len(sample)/8
+	Needles    int           // needles per length drawn from the sample; default 20
+	Absent     int           // needles per length not present in the sample; default Needles/10
+	Algorithms []string      // algorithms to time; default all registered
+	MinTime    time.Duration // minimum time spent per length and algorithm; default 0 (one run)
+	Seed       int64         // seed for drawing the needles; default 1
+}
+
+// Errors
+var (
+	SAMPLESHORT = errors.New("calibration sample is too short")
+	NOWINNER    = errors.New("no algorithm accepted all needle lengths of a band")
+)
+
+// Calibrate times Index, Count and FindAll of every registered algorithm (or those named in opts)
+// on needles drawn from sample across the needle lengths in opts, finds the crossover points
+// between the fastest algorithms and returns them as dispatch profile.
+// Only the lengths accepted by one of the algorithms are timed; needles shorter than all
+// of them are routed as in NaturalText. The profile may be saved as JSON (Profile.Save) and
+// loaded at startup (LoadProfile).
+// Algorithms that panic or return wrong counts on the sample are skipped.
+func Calibrate(sample []byte, opts *CalibrateOptions) (*Profile, error) {
+	var o CalibrateOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Name == "" {
+		o.Name = "calibrated"
+	}
+	if o.MaxNeedle < 1 {
+		o.MaxNeedle = len(sample) / 8
+	}
+	if o.Needles < 1 {
+		o.Needles = 20
+	}
+	if o.Absent < 1 {
+		o.Absent = o.Needles / 10
+	}
+	if o.Seed == 0 {
+		o.Seed = 1
+	}
+	if len(sample) < 16 {
+		return nil, SAMPLESHORT
+	}
+
+	var algos []Algorithm
+	if len(o.Algorithms) == 0 {
+		algos = registry.All()
+	} else {
+		for _, name := range o.Algorithms {
+			a, e := registry.Lookup(name)
+			if e != nil {
+				return nil, errors.New(name + ": " + e.Error())
+			}
+			algos = append(algos, a)
+		}
+	}
+
+	lengths := calibrationLengths(o.Lengths, o.MaxNeedle, len(sample)/2, algos)
+	if len(lengths) == 0 {
+		return nil, NOWINNER
+	}
+
+	rnd := rand.New(rand.NewSource(o.Seed))
+	disqualified := map[string]bool{}
+
+	// timings[i][name] for lengths[i]
+	timings := make([]map[string]time.Duration, len(lengths))
+	for i, m := range lengths {
+		ns, e := needles.Draw(rnd, sample, m, m, o.Needles, o.Absent)
+		if e != nil {
+			return nil, e
+		}
+		want := make([][]int, len(ns))
+		for k := range ns {
+			want[k] = referenceFindAll(sample, ns[k])
+		}
+		timings[i] = map[string]time.Duration{}
+		for _, a := range algos {
+			if disqualified[a.Name] || !a.Accepts(m) {
+				continue
+			}
+			d, ok := timeAlgorithm(a.Searcher, sample, ns, want, o.MinTime)
+			if !ok {
+				disqualified[a.Name] = true
+				continue
+			}
+			timings[i][a.Name] = d
+		}
+	}
+	for i := range timings {
+		for name := range disqualified {
+			delete(timings[i], name)
+		}
+	}
+
+	return calibrationProfile(o.Name, lengths, timings)
+}
+
+// calibrationLengths returns the sorted needle lengths to time accepted by one of algos,
+// starting with the shortest length one of them accepts
+func calibrationLengths(lengths []int, maxNeedle, limit int, algos []Algorithm) []int {
+	if maxNeedle > limit {
+		maxNeedle = limit
+	}
+	if len(lengths) == 0 {
+		for m := 1; m <= maxNeedle; m *= 2 {
+			lengths = append(lengths, m)
+			if m > 2 && m+m/2 <= maxNeedle {
+				lengths = append(lengths, m+m/2)
+			}
+		}
+	}
+	shortest := 0
+	for _, a := range algos {
+		if m := a.MinNeedle; m <= limit && (shortest == 0 || m < shortest) {
+			shortest = m
+		}
+	}
+	if shortest < 1 {
+		if len(algos) == 0 {
+			return nil
+		}
+		shortest = 1
+	}
+	seen := map[int]bool{}
+	var ls []int
+	for _, m := range append([]int{shortest}, lengths...) {
+		if m < 1 || m > limit || seen[m] {
+			continue
+		}
+		for _, a := range algos {
+			if a.Accepts(m) {
+				seen[m] = true
+				ls = append(ls, m)
+				break
+			}
+		}
+	}
+	sort.Ints(ls)
+	return ls
+}
+
+// referenceFindAll returns the (overlapping) occurrences of needle using bytes.Index
+func referenceFindAll(haystack, needle []byte) (found []int) {
+	for off := 0; ; off++ {
+		idx := bytes.Index(haystack[off:], needle)
+		if idx == -1 {
+			return found
+		}
+		off += idx
+		found = append(found, off)
+	}
+}
+
+// timeAlgorithm runs Index, Count and FindAll of s for all needles in sample until
+// minTime has passed. It returns false if s panics or its results differ from want.
+func timeAlgorithm(s Searcher, sample []byte, needles [][]byte, want [][]int, minTime time.Duration) (d time.Duration, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	runs := 0
+	start := time.Now()
+	for {
+		for k := range needles {
+			first := -1
+			if len(want[k]) > 0 {
+				first = want[k][0]
+			}
+			i, e := s.Index(&sample, &needles[k])
+			if e != nil || i != first {
+				return 0, false
+			}
+			c, e := s.Count(&sample, &needles[k])
+			if e != nil || c != len(want[k]) {
+				return 0, false
+			}
+			found, e := s.FindAll(&sample, &needles[k])
+			if e != nil || !equalInts(found, want[k]) {
+				return 0, false
+			}
+		}
+		runs++
+		if d = time.Since(start); d >= minTime {
+			break
+		}
+	}
+	return d / time.Duration(runs), true
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// calibrationProfile merges the fastest algorithm per length into bands
+// switching at the (geometric) middle between two timed lengths;
+// the needles shorter than lengths[0] get the bands of NaturalText
+func calibrationProfile(name string, lengths []int, timings []map[string]time.Duration) (*Profile, error) {
+	last := len(lengths) - 1
+	winners := make([]string, len(lengths))
+	for i := range lengths {
+		var best time.Duration
+		for n, d := range timings[i] {
+			if i == last {
+				// the last band takes all longer needles
+				if a, _ := registry.Lookup(n); a.MaxNeedle != 0 {
+					continue
+				}
+			}
+			if winners[i] == "" || d < best || (d == best && n < winners[i]) {
+				winners[i], best = n, d
+			}
+		}
+		if winners[i] == "" {
+			return nil, NOWINNER
+		}
+	}
+
+	p := &Profile{Name: name, Bands: headBands(naturalTextProfile, lengths[0])}
+	for i := 0; i < last; i++ {
+		if winners[i] == winners[i+1] {
+			continue
+		}
+		below := int(math.Sqrt(float64(lengths[i]) * float64(lengths[i+1])))
+		if below <= lengths[i] {
+			below = lengths[i] + 1
+		}
+		// keep the switch point within the limits of both algorithms
+		if a, _ := registry.Lookup(winners[i]); a.MaxNeedle != 0 && below > a.MaxNeedle+1 {
+			below = a.MaxNeedle + 1
+		}
+		if a, _ := registry.Lookup(winners[i+1]); below < a.MinNeedle {
+			below = a.MinNeedle
+		}
+		if n := len(p.Bands); n > 0 && below <= p.Bands[n-1].Below {
+			// winners[i] is squeezed out by the limits of its neighbours
+			continue
+		}
+		p.Bands = append(p.Bands, Band{below, winners[i]})
+	}
+	p.Bands = append(p.Bands, Band{0, winners[last]})
+
+	if e := p.Validate(); e != nil {
+		return nil, e
+	}
+	return p, nil
+}
+
+// headBands returns the bands of p for the needles shorter than below
+func headBands(p *Profile, below int) (bands []Band) {
+	if below <= 1 {
+		return nil
+	}
+	for _, b := range p.Bands {
+		if b.Below == 0 || b.Below >= below {
+			return append(bands, Band{below, b.Algorithm})
+		}
+		bands = append(bands, b)
+	}
+	return bands
+}
+
+// Encode writes the profile as JSON to w.
+func (p *Profile) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(p)
+}
+
+// DecodeProfile reads a JSON profile from r and validates it.
+func DecodeProfile(r io.Reader) (*Profile, error) {
+	p := &Profile{}
+	if e := json.NewDecoder(r).Decode(p); e != nil {
+		return nil, e
+	}
+	if e := p.Validate(); e != nil {
+		return nil, e
+	}
+	return p, nil
+}
+
+// Save writes the profile as JSON to the file path.
+func (p *Profile) Save(path string) error {
+	var buf bytes.Buffer
+	if e := p.Encode(&buf); e != nil {
+		return e
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// LoadProfile reads a JSON profile from the file path and validates it,
+// i.e. to load a calibrated profile at startup:
+//
+//	p, err := bmatch.LoadProfile("bmatch-profile.json")
+//	if err == nil {
+//		err = bmatch.SetDefaultProfile(p)
+//	}
+func LoadProfile(path string) (*Profile, error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+	return DecodeProfile(f)
+}
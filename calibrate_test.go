@@ -0,0 +1,148 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+func TestCalibrate_ProfileRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	sample := make([]byte, 1<<16)
+	for i := range sample {
+		sample[i] = "ACGT"[rnd.Intn(4)]
+	}
+
+	p, e := Calibrate(sample, &CalibrateOptions{
+		Name:       "test-dna",
+		Lengths:    []int{2, 8, 64, 512},
+		Needles:    5,
+		Algorithms: []string{"memchr", "bs_fsbndm", "bhsearch"},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if p.Name != "test-dna" || p.Bands[0].Algorithm != "memchr" || p.Bands[len(p.Bands)-1].Below != 0 {
+		t.Errorf("unexpected profile %+v", p)
+	}
+
+	var buf bytes.Buffer
+	if e := p.Encode(&buf); e != nil {
+		t.Fatal(e)
+	}
+	q, e := DecodeProfile(&buf)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(q.Bands) != len(p.Bands) || q.Name != p.Name {
+		t.Fatalf("decoded %+v; want %+v", q, p)
+	}
+	for i := range p.Bands {
+		if q.Bands[i] != p.Bands[i] {
+			t.Errorf("decoded band %v = %v; want %v", i, q.Bands[i], p.Bands[i])
+		}
+	}
+
+	for _, m := range []int{1, 3, 20, 300} {
+		needle := sample[100 : 100+m]
+		want, _ := bytesIndexCount(&sample, &needle)
+		if c, _ := CountWith(q, &sample, &needle); c != want {
+			t.Errorf("CountWith(calibrated) m=%v = %v; want %v", m, c, want)
+		}
+	}
+}
+
+func TestCalibrate_Profile(t *testing.T) {
+	lengths := []int{1, 2, 4, 8}
+	timings := []map[string]time.Duration{
+		{"memchr": 1},
+		{"bs_fsbndm": 2, "bhsearch": 3},
+		{"bs_fsbndm": 3, "bhsearch": 2},
+		{"bs_fsbndm": 1, "bhsearch": 2},
+	}
+	p, e := calibrationProfile("t", lengths, timings)
+	if e != nil {
+		t.Fatal(e)
+	}
+	want := []Band{{2, "memchr"}, {3, "bs_fsbndm"}, {5, "bhsearch"}, {0, "bs_fsbndm"}}
+	if len(p.Bands) != len(want) {
+		t.Fatalf("bands %v; want %v", p.Bands, want)
+	}
+	for i := range want {
+		if p.Bands[i] != want[i] {
+			t.Errorf("bands %v; want %v", p.Bands, want)
+			break
+		}
+	}
+}
+
+// without an algorithm for single bytes the short needles are routed as in NaturalText
+func TestCalibrate_NoMemchr(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	sample := make([]byte, 1<<14)
+	for i := range sample {
+		sample[i] = "abcdefgh"[rnd.Intn(8)]
+	}
+	p, e := Calibrate(sample, &CalibrateOptions{
+		Lengths:    []int{1, 2, 3, 8, 64},
+		Needles:    4,
+		Algorithms: []string{"bhsearch", "bh2search"},
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if p.Bands[0] != (Band{2, "memchr"}) || p.Bands[1].Below != 3 || p.Bands[1].Algorithm != naturalTextProfile.Bands[1].Algorithm {
+		t.Errorf("bands %v; want memchr below 2 and %s below 3", p.Bands, naturalTextProfile.Bands[1].Algorithm)
+	}
+	for _, m := range []int{1, 2, 3, 50} {
+		needle := sample[200 : 200+m]
+		want, _ := bytesIndexCount(&sample, &needle)
+		if c, _ := CountWith(p, &sample, &needle); c != want {
+			t.Errorf("CountWith(calibrated) m=%v = %v; want %v", m, c, want)
+		}
+	}
+
+	// the shortest length accepted is timed in any case
+	p, e = Calibrate(sample, &CalibrateOptions{Lengths: []int{1, 2}, Algorithms: []string{"bhsearch"}})
+	if e != nil || p.Bands[len(p.Bands)-1] != (Band{0, "bhsearch"}) || p.Bands[len(p.Bands)-2].Below != 3 {
+		t.Errorf("bhsearch only: %v, %v", p, e)
+	}
+}
+
+func TestCalibrate_Lengths(t *testing.T) {
+	var algos []Algorithm
+	for _, name := range []string{"bs_fsbndm", "epsm"} {
+		a, _ := registry.Lookup(name)
+		algos = append(algos, a)
+	}
+	got := calibrationLengths([]int{1, 4, 4, 9, 100}, 0, 50, algos)
+	want := []int{2, 4, 9}
+	if !equalInts(got, want) {
+		t.Errorf("calibrationLengths = %v; want %v", got, want)
+	}
+}
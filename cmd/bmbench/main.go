@@ -0,0 +1,339 @@
+// go package main
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * bmbench times the registered bmatch algorithms on a corpus of your choice.
+ *
+ *	bmbench -corpus file [flags]
+ *
+ * For each band of needle lengths it draws needles from the corpus (present) and makes
+ * needles not in the corpus by changing one of their bytes to a byte the corpus lacks
+ * (absent), as bmatch.Calibrate does. Each algorithm then runs the needles
+ * repeatedly for at least -time and its time per needle and search (ns/op) and its
+ * throughput over the corpus (MB/s) are reported as table, CSV or JSON.
+ *
+ * Besides the registered algorithms "bmatch" (the dispatching functions) and "bytes"
+ * (bytes.Index) may be listed in -algs. Algorithms not accepting every length of a band
+ * or returning wrong results are reported with an error instead of timings.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AndreasBriese/bmatch"
+	"github.com/AndreasBriese/bmatch/internal/needles"
+)
+
+// band of needle lengths Min..Max (inclusive)
+type band struct {
+	Min, Max int
+}
+
+func (b band) String() string {
+	return fmt.Sprintf("%d-%d", b.Min, b.Max)
+}
+
+// result of one algorithm on one band
+type result struct {
+	Algorithm  string  `json:"algorithm"`
+	Band       string  `json:"band"`
+	Needles    int     `json:"needles"`
+	NsPerOp    float64 `json:"ns_per_op"`
+	MBPerS     float64 `json:"mb_per_s"`
+	Error      string  `json:"error,omitempty"`
+	operations int
+}
+
+type config struct {
+	bands   []band
+	present int
+	absent  int
+	algs    []string
+	op      string
+	minTime time.Duration
+	seed    int64
+}
+
+// Errors
+var (
+	BADBAND = errors.New("bands are given as min-max[,min-max ...] with 0 < min <= max")
+	BADOP   = errors.New("op is one of count, index, findall")
+)
+
+// searcher runs one operation of an algorithm
+type searcher func(haystack, needle *[]byte) (int, error)
+
+func main() {
+	var (
+		corpus, bands, algs, format string
+		c                           config
+		e                           error
+	)
+	flag.StringVar(&corpus, "corpus", "", "corpus file (required)")
+	flag.StringVar(&bands, "bands", "1-1,2-8,9-32,33-256,257-1024", "needle length bands min-max, comma separated")
+	flag.IntVar(&c.present, "n", 500, "needles per band present in the corpus")
+	flag.IntVar(&c.absent, "absent", 20, "needles per band absent from the corpus")
+	flag.StringVar(&algs, "algs", "", "comma separated algorithms; default: all registered, bmatch and bytes")
+	flag.StringVar(&c.op, "op", "count", "operation timed: count, index or findall")
+	flag.StringVar(&format, "format", "table", "output format: table, csv or json")
+	flag.DurationVar(&c.minTime, "time", 200*time.Millisecond, "minimum time per algorithm and band")
+	flag.Int64Var(&c.seed, "seed", 1, "seed of the needle generator")
+	flag.Parse()
+
+	if corpus == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if e = checkFormat(format); e != nil {
+		fail(e)
+	}
+	hay, e := ioutil.ReadFile(corpus)
+	if e != nil {
+		fail(e)
+	}
+	if c.bands, e = parseBands(bands); e != nil {
+		fail(e)
+	}
+	if algs == "" {
+		for _, a := range bmatch.Algorithms() {
+			c.algs = append(c.algs, a.Name)
+		}
+		c.algs = append(c.algs, "bmatch", "bytes")
+	} else {
+		c.algs = strings.Split(algs, ",")
+	}
+
+	results, e := bench(hay, &c)
+	if e != nil {
+		fail(e)
+	}
+	if e = write(os.Stdout, format, results); e != nil {
+		fail(e)
+	}
+}
+
+func fail(e error) {
+	fmt.Fprintln(os.Stderr, "bmbench:", e)
+	os.Exit(2)
+}
+
+// parseBands parses "1-1,2-8,9-32"
+func parseBands(s string) (bands []band, e error) {
+	for _, f := range strings.Split(s, ",") {
+		lohi := strings.SplitN(strings.TrimSpace(f), "-", 2)
+		if len(lohi) != 2 {
+			return nil, BADBAND
+		}
+		var b band
+		if b.Min, e = strconv.Atoi(lohi[0]); e != nil {
+			return nil, BADBAND
+		}
+		if b.Max, e = strconv.Atoi(lohi[1]); e != nil {
+			return nil, BADBAND
+		}
+		if b.Min < 1 || b.Max < b.Min {
+			return nil, BADBAND
+		}
+		bands = append(bands, b)
+	}
+	return bands, nil
+}
+
+// lookup returns the searcher for the operation op of the algorithm name
+// and the needle lengths it accepts
+func lookup(name, op string) (s searcher, accepts func(int) bool, e error) {
+	accepts = func(int) bool { return true }
+	var index, count func(haystack, needle *[]byte) (int, error)
+	var findAll func(haystack, needle *[]byte) ([]int, error)
+	switch name {
+	case "bmatch":
+		index, count, findAll = bmatch.Index, bmatch.Count, bmatch.FindAll
+	case "bytes":
+		index = func(haystack, needle *[]byte) (int, error) {
+			return bytes.Index(*haystack, *needle), nil
+		}
+		findAll = func(haystack, needle *[]byte) (found []int, e error) {
+			hay, off := *haystack, 0
+			for {
+				idx := bytes.Index(hay[off:], *needle)
+				if idx < 0 {
+					return found, nil
+				}
+				found = append(found, off+idx)
+				off += idx + 1
+			}
+		}
+		count = func(haystack, needle *[]byte) (int, error) {
+			found, _ := findAll(haystack, needle)
+			return len(found), nil
+		}
+	default:
+		a, e := lookupAlgorithm(name)
+		if e != nil {
+			return nil, nil, e
+		}
+		index, count, findAll = a.Searcher.Index, a.Searcher.Count, a.Searcher.FindAll
+		accepts = a.Accepts
+	}
+	switch op {
+	case "count":
+		s = count
+	case "index":
+		s = index
+	case "findall":
+		s = func(haystack, needle *[]byte) (int, error) {
+			found, e := findAll(haystack, needle)
+			return len(found), e
+		}
+	default:
+		return nil, nil, BADOP
+	}
+	return s, accepts, nil
+}
+
+func lookupAlgorithm(name string) (bmatch.Algorithm, error) {
+	for _, a := range bmatch.Algorithms() {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return bmatch.Algorithm{}, fmt.Errorf("unknown algorithm %q", name)
+}
+
+// bench times all algorithms of c on all bands
+func bench(hay []byte, c *config) (results []result, e error) {
+	rnd := rand.New(rand.NewSource(c.seed))
+	ref, _, e := lookup("bytes", c.op)
+	if e != nil {
+		return nil, e
+	}
+	for _, b := range c.bands {
+		ns, e := needles.Draw(rnd, hay, b.Min, b.Max, c.present, c.absent)
+		if e != nil {
+			return nil, e
+		}
+		want := make([]int, len(ns))
+		for k := range ns {
+			want[k], _ = ref(&hay, &ns[k])
+		}
+		for _, name := range c.algs {
+			s, accepts, e := lookup(name, c.op)
+			if e != nil {
+				return nil, e
+			}
+			r := result{Algorithm: name, Band: b.String(), Needles: len(ns)}
+			if !accepts(b.Min) || !accepts(b.Max) {
+				r.Error = "needle lengths not accepted"
+			} else {
+				r.Error = timeSearcher(s, hay, ns, want, c.minTime, &r)
+			}
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// timeSearcher runs all needles until minTime has passed and fills r's timings;
+// it returns the reason if s fails
+func timeSearcher(s searcher, hay []byte, needles [][]byte, want []int, minTime time.Duration, r *result) (failure string) {
+	defer func() {
+		if p := recover(); p != nil {
+			failure = fmt.Sprint("panic: ", p)
+		}
+	}()
+	start := time.Now()
+	var d time.Duration
+	for {
+		for k := range needles {
+			got, e := s(&hay, &needles[k])
+			if e != nil {
+				return e.Error()
+			}
+			if got != want[k] {
+				return fmt.Sprintf("wrong result for needle of length %d: %d, want %d", len(needles[k]), got, want[k])
+			}
+		}
+		r.operations += len(needles)
+		if d = time.Since(start); d >= minTime {
+			break
+		}
+	}
+	r.NsPerOp = float64(d.Nanoseconds()) / float64(r.operations)
+	r.MBPerS = float64(len(hay)) / r.NsPerOp * 1e3
+	return ""
+}
+
+// checkFormat returns an error unless write knows format
+func checkFormat(format string) error {
+	switch format {
+	case "table", "csv", "json":
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// write prints results in format (table, csv or json)
+func write(w io.Writer, format string, results []result) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"algorithm", "band", "needles", "ns_per_op", "mb_per_s", "error"})
+		for _, r := range results {
+			cw.Write([]string{r.Algorithm, r.Band, strconv.Itoa(r.Needles),
+				strconv.FormatFloat(r.NsPerOp, 'f', 0, 64),
+				strconv.FormatFloat(r.MBPerS, 'f', 2, 64), r.Error})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "algorithm\tband\tneedles\tns/op\tMB/s\t")
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t-\t-\t  %s\n", r.Algorithm, r.Band, r.Needles, r.Error)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%.0f\t%.2f\t\n", r.Algorithm, r.Band, r.Needles, r.NsPerOp, r.MBPerS)
+		}
+		return tw.Flush()
+	}
+	return checkFormat(format)
+}
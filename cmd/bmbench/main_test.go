@@ -0,0 +1,106 @@
+// go package main
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreasBriese/bmatch/internal/needles"
+)
+
+func TestParseBands(t *testing.T) {
+	bands, e := parseBands("1-1, 2-8,9-32")
+	if e != nil || len(bands) != 3 || bands[1] != (band{2, 8}) {
+		t.Fatalf("parseBands = %v, %v", bands, e)
+	}
+	for _, s := range []string{"", "1", "0-3", "4-2", "a-b", "1-2,"} {
+		if _, e := parseBands(s); e != BADBAND {
+			t.Errorf("parseBands(%q) = %v; want %v", s, e, BADBAND)
+		}
+	}
+}
+
+func TestBench(t *testing.T) {
+	hay := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog ", 200))
+	c := &config{
+		bands:   []band{{1, 1}, {2, 16}},
+		present: 20,
+		absent:  2,
+		algs:    []string{"memchr", "bs_fsbndm", "bmatch", "bytes"},
+		op:      "count",
+		minTime: time.Millisecond,
+		seed:    1,
+	}
+	results, e := bench(hay, c)
+	if e != nil || len(results) != 8 {
+		t.Fatalf("bench = %d results, %v", len(results), e)
+	}
+	for _, r := range results {
+		rejected := r.Band == "1-1" && r.Algorithm == "bs_fsbndm" || r.Band == "2-16" && r.Algorithm == "memchr"
+		if rejected != (r.Error != "") || !rejected && (r.NsPerOp <= 0 || r.MBPerS <= 0) {
+			t.Errorf("result %+v", r)
+		}
+	}
+
+	var out bytes.Buffer
+	if e := write(&out, "json", results); e != nil {
+		t.Fatal(e)
+	}
+	var decoded []result
+	if e := json.Unmarshal(out.Bytes(), &decoded); e != nil || len(decoded) != len(results) || decoded[2].Algorithm != "bmatch" {
+		t.Fatalf("json: %v, %v", decoded, e)
+	}
+	out.Reset()
+	if e := write(&out, "csv", results); e != nil {
+		t.Fatal(e)
+	}
+	if records, e := csv.NewReader(&out).ReadAll(); e != nil || len(records) != len(results)+1 {
+		t.Fatalf("csv: %v, %v", records, e)
+	}
+	if e := write(&out, "table", results); e != nil {
+		t.Fatal(e)
+	}
+	if _, e := bench(hay, &config{bands: c.bands, algs: c.algs, op: "nope"}); e != BADOP {
+		t.Fatalf("bench op nope: %v", e)
+	}
+}
+
+func TestBench_Errors(t *testing.T) {
+	c := &config{bands: []band{{1, 4}}, present: 2, algs: []string{"bytes"}, op: "count", seed: 1}
+	if _, e := bench(nil, c); e != needles.EMPTY {
+		t.Errorf("empty corpus: %v; want %v", e, needles.EMPTY)
+	}
+	for _, f := range []string{"table", "csv", "json"} {
+		if e := checkFormat(f); e != nil {
+			t.Errorf("checkFormat(%q): %v", f, e)
+		}
+	}
+	if e := checkFormat("xml"); e == nil {
+		t.Error("checkFormat(xml): no error")
+	}
+}
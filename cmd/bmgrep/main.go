@@ -0,0 +1,505 @@
+// go package main
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * bmgrep is a fixed string grep on bmatch.
+ *
+ *	bmgrep [flags] pattern [file ...]
+ *	bmgrep [flags] -e pattern [-e pattern ...] [file ...]
+ *
+ * Without files bmgrep reads stdin, with -r and without files the working directory.
+ * Flags (single letters combine as in grep, i.e. -ni for -n -i, -ie pattern):
+ *
+ *	-c	print the number of matching lines per file
+ *	-o	print only the matches, one per line
+ *	-b	print the byte offset of the line (with -o: of the match)
+ *	-n	print the line number
+ *	-i	ignore case of ASCII letters
+ *	-r	search the files below directories
+ *	-e	pattern; may be given several times
+ *	-j	number of files searched in parallel (default: number of CPUs)
+ *
+ * Files are read in windows of 1 MB of whole lines, so they need not fit in memory.
+ * Their output is streamed in file order; files searched ahead of their turn hold
+ * at most 1 MB of output each.
+ * A file holding a NUL byte within its first 8 KB is binary: bmgrep only tells
+ * whether it matches. The exit status is 0 if a line matched, 1 if none and 2 on errors.
+ */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/AndreasBriese/bmatch"
+)
+
+// patterns collects the -e flags
+type patterns []string
+
+func (p *patterns) String() string {
+	return fmt.Sprint(*p)
+}
+
+func (p *patterns) Set(s string) error {
+	*p = append(*p, s)
+	return nil
+}
+
+type options struct {
+	count      bool
+	only       bool
+	byteOffset bool
+	lineNumber bool
+	ignoreCase bool
+	recursive  bool
+	withName   bool
+	needles    [][]byte
+	window     int // bytes searched at once; 0: grepWindow
+}
+
+// bytes of a file checked for NUL
+const binaryProbe = 8 << 10
+
+// default window size of grepReader
+const grepWindow = 1 << 20
+
+// output of a file is sent to the printer in blocks of outBlock bytes,
+// at most outBlocks ahead of it
+const (
+	outBlock  = 64 << 10
+	outBlocks = 16
+)
+
+// match is the match of one of the needles
+type match struct {
+	pos bmatch.Position
+	len int
+}
+
+// result of a file; matched and err are set before out is closed
+type result struct {
+	out     chan []byte
+	matched bool
+	err     error
+}
+
+// blockWriter sends copies of the blocks written to the printer
+type blockWriter chan<- []byte
+
+func (w blockWriter) Write(p []byte) (int, error) {
+	w <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+func main() {
+	var (
+		o    options
+		pats patterns
+		jobs int
+	)
+	flag.BoolVar(&o.count, "c", false, "print the number of matching lines per file")
+	flag.BoolVar(&o.only, "o", false, "print only the matches")
+	flag.BoolVar(&o.byteOffset, "b", false, "print the byte offset of the line (with -o: of the match)")
+	flag.BoolVar(&o.lineNumber, "n", false, "print the line number")
+	flag.BoolVar(&o.ignoreCase, "i", false, "ignore case of ASCII letters")
+	flag.BoolVar(&o.recursive, "r", false, "search the files below directories")
+	flag.Var(&pats, "e", "pattern; may be given several times")
+	flag.IntVar(&jobs, "j", runtime.NumCPU(), "number of files searched in parallel")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: bmgrep [flags] pattern [file ...]\n       bmgrep [flags] -e pattern [-e pattern ...] [file ...]")
+		flag.PrintDefaults()
+	}
+	flag.CommandLine.Parse(splitFlags(os.Args[1:]))
+
+	args := flag.Args()
+	if len(pats) == 0 {
+		if len(args) == 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		pats, args = patterns{args[0]}, args[1:]
+	}
+	for _, p := range pats {
+		if len(p) == 0 {
+			fmt.Fprintln(os.Stderr, "bmgrep: empty pattern")
+			os.Exit(2)
+		}
+		needle := []byte(p)
+		if o.ignoreCase {
+			needle = foldASCII(needle)
+		}
+		o.needles = append(o.needles, needle)
+	}
+
+	if len(args) == 0 && o.recursive {
+		args = []string{"."}
+	}
+	files, errs := collectFiles(args, o.recursive)
+	o.withName = len(args) > 1 || o.recursive
+
+	status := run(files, &o, jobs, os.Stdout, os.Stderr)
+	if errs {
+		status = 2
+	}
+	os.Exit(status)
+}
+
+// flags taking a value
+const valueFlags = "ej"
+
+// splitFlags splits combined single letter flags as grep does: -ni is -n -i,
+// -ie pattern is -i -e pattern and -epattern is -e pattern.
+// It stops at the first argument that isn't a flag, like flag.Parse.
+func splitFlags(args []string) (split []string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" || len(a) < 2 || a[0] != '-' || a[1] == '-' {
+			return append(split, args[i:]...)
+		}
+		if len(a) == 2 || strings.IndexByte(a, '=') >= 0 {
+			// a single flag, -j=4
+			split = append(split, a)
+			if len(a) == 2 && strings.IndexByte(valueFlags, a[1]) >= 0 && i+1 < len(args) {
+				i++
+				split = append(split, args[i])
+			}
+			continue
+		}
+		for k := 1; k < len(a); k++ {
+			split = append(split, "-"+a[k:k+1])
+			if strings.IndexByte(valueFlags, a[k]) < 0 {
+				continue
+			}
+			// the rest of the argument or the next one is the value
+			if k+1 < len(a) {
+				split = append(split, a[k+1:])
+			} else if i+1 < len(args) {
+				i++
+				split = append(split, args[i])
+			}
+			break
+		}
+	}
+	return split
+}
+
+// collectFiles expands the directories in args if recursive; "-" is stdin
+func collectFiles(args []string, recursive bool) (files []string, errs bool) {
+	if len(args) == 0 {
+		return []string{"-"}, false
+	}
+	for _, arg := range args {
+		fi, e := os.Stat(arg)
+		if arg == "-" || e == nil && !fi.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		if e != nil || !recursive {
+			if e == nil {
+				e = fmt.Errorf("%s: is a directory", arg)
+			}
+			fmt.Fprintln(os.Stderr, "bmgrep:", e)
+			errs = true
+			continue
+		}
+		filepath.Walk(arg, func(path string, info os.FileInfo, e error) error {
+			if e != nil {
+				fmt.Fprintln(os.Stderr, "bmgrep:", e)
+				errs = true
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	return files, errs
+}
+
+// run greps the files with jobs workers and prints their results in order.
+// The output of the file in turn is streamed; workers ahead of it block
+// once they hold outBlocks blocks of output.
+func run(files []string, o *options, jobs int, stdout, stderr io.Writer) (status int) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	var (
+		results = make([]*result, len(files))
+		next    = make(chan int)
+	)
+	for i := range results {
+		results[i] = &result{out: make(chan []byte, outBlocks)}
+	}
+	// files are handed out in order, so the file in turn always has a worker
+	for w := 0; w < jobs; w++ {
+		go func() {
+			for i := range next {
+				grepPath(files[i], o, results[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			next <- i
+		}
+		close(next)
+	}()
+
+	status = 1
+	w := bufio.NewWriterSize(stdout, 1<<16)
+	defer w.Flush()
+	for _, r := range results {
+		for b := range r.out {
+			w.Write(b)
+		}
+		if r.err != nil {
+			w.Flush()
+			fmt.Fprintln(stderr, "bmgrep:", r.err)
+			status = 2
+			continue
+		}
+		if r.matched && status == 1 {
+			status = 0
+		}
+	}
+	return status
+}
+
+// grepPath greps the file name into r and closes r.out
+func grepPath(name string, o *options, r *result) {
+	defer close(r.out)
+	f := os.Stdin
+	if name == "-" {
+		name = "(standard input)"
+	} else {
+		var e error
+		if f, e = os.Open(name); e != nil {
+			r.err = e
+			return
+		}
+		defer f.Close()
+	}
+	out := bufio.NewWriterSize(blockWriter(r.out), outBlock)
+	r.matched, r.err = grepReader(name, f, o, out)
+	if e := out.Flush(); r.err == nil {
+		r.err = e
+	}
+}
+
+// grep writes the output for the file name holding data to out
+func grep(name string, data []byte, o *options, out *bytes.Buffer) (bool, error) {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	return grepReader(name, bytes.NewReader(data), o, w)
+}
+
+// grepReader writes the output for the file name read from r to out.
+// The file is searched in windows of whole lines (o.window bytes, grown for longer
+// lines), each followed by the first m-1 bytes of the next one to find the needles
+// starting in the window; -i folds one window at a time.
+func grepReader(name string, r io.Reader, o *options, out *bufio.Writer) (bool, error) {
+	maxM := 0
+	for _, needle := range o.needles {
+		if len(needle) > maxM {
+			maxM = len(needle)
+		}
+	}
+	size := o.window
+	if size < 1 {
+		size = grepWindow
+	}
+	if size < 2*maxM {
+		size = 2 * maxM
+	}
+
+	var (
+		buf    = make([]byte, size)
+		folded []byte
+		n      int  // bytes in buf
+		eof    bool // all bytes read
+		binary bool
+		base   int // offset of buf[0] in the file
+		line   int // lines before buf[0]
+		lines  int // matching lines
+		last   = -1
+		end    int // of the last match printed with -o
+	)
+
+	prefix := func(line, offset int) {
+		if o.withName {
+			out.WriteString(name)
+			out.WriteByte(':')
+		}
+		if o.lineNumber {
+			fmt.Fprintf(out, "%d:", line)
+		}
+		if o.byteOffset {
+			fmt.Fprintf(out, "%d:", offset)
+		}
+	}
+
+	for first := true; ; first = false {
+		k, e := io.ReadFull(r, buf[n:])
+		n += k
+		switch e {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			eof = true
+		default:
+			return lines > 0, e
+		}
+		if first {
+			probe := buf[:n]
+			if len(probe) > binaryProbe {
+				probe = probe[:binaryProbe]
+			}
+			binary = bytes.IndexByte(probe, 0) >= 0
+		}
+
+		// the window ends after the last newline leaving m-1 bytes to look ahead
+		cut := n
+		if !eof {
+			if cut = bytes.LastIndexByte(buf[:n-maxM+1], '\n') + 1; cut == 0 {
+				// a line longer than the buffer
+				grown := make([]byte, 2*len(buf))
+				n = copy(grown, buf[:n])
+				buf = grown
+				continue
+			}
+		}
+
+		hay := buf[:n]
+		if o.ignoreCase {
+			folded = foldInto(folded, hay)
+			hay = folded
+		}
+		matches, e := findMatches(hay, cut, o.needles)
+		if e != nil {
+			return lines > 0, e
+		}
+
+		for _, m := range matches {
+			ln := line + m.pos.Line
+			if ln != last {
+				lines++
+			}
+			switch {
+			case o.count:
+			case binary:
+				fmt.Fprintf(out, "Binary file %s matches\n", name)
+				return true, nil
+			case o.only:
+				// leftmost, non overlapping matches
+				if base+m.pos.Offset < end {
+					continue
+				}
+				end = base + m.pos.Offset + m.len
+				prefix(ln, base+m.pos.Offset)
+				out.Write(buf[m.pos.Offset : m.pos.Offset+m.len])
+				out.WriteByte('\n')
+			case ln != last:
+				prefix(ln, base+m.pos.LineStart)
+				out.Write(buf[m.pos.LineStart:m.pos.LineEnd])
+				out.WriteByte('\n')
+			}
+			last = ln
+		}
+
+		if eof {
+			break
+		}
+		line += bytes.Count(buf[:cut], newline)
+		base += cut
+		n = copy(buf, buf[cut:n])
+	}
+
+	if o.count {
+		if o.withName {
+			out.WriteString(name)
+			out.WriteByte(':')
+		}
+		fmt.Fprintln(out, lines)
+	}
+	return lines > 0, nil
+}
+
+var newline = []byte{'\n'}
+
+// findMatches returns the matches of the needles in hay starting before cut,
+// ordered by offset, longer needles first
+func findMatches(hay []byte, cut int, needles [][]byte) (matches []match, e error) {
+	for _, needle := range needles {
+		if len(needle) > len(hay) {
+			continue
+		}
+		positions, e := bmatch.FindAllPositions(&hay, &needle)
+		if e != nil {
+			return nil, e
+		}
+		for _, p := range positions {
+			if p.Offset >= cut {
+				break
+			}
+			matches = append(matches, match{p, len(needle)})
+		}
+	}
+	if len(needles) > 1 {
+		sort.SliceStable(matches, func(i, j int) bool {
+			if matches[i].pos.Offset != matches[j].pos.Offset {
+				return matches[i].pos.Offset < matches[j].pos.Offset
+			}
+			return matches[i].len > matches[j].len
+		})
+	}
+	return matches, nil
+}
+
+// foldASCII returns a copy of b with the ASCII letters in lower case
+func foldASCII(b []byte) []byte {
+	return foldInto(nil, b)
+}
+
+// foldInto is foldASCII reusing the buffer dst
+func foldInto(dst, b []byte) []byte {
+	if cap(dst) < len(b) {
+		dst = make([]byte, len(b))
+	}
+	dst = dst[:len(b)]
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		dst[i] = c
+	}
+	return dst
+}
@@ -0,0 +1,178 @@
+// go package main
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	data := []byte("Hello world\nfoo bar\nhello HELLO\nworldworld")
+	for _, tc := range []struct {
+		o    options
+		want string
+	}{
+		{options{needles: [][]byte{[]byte("hello")}}, "hello HELLO\n"},
+		{options{needles: [][]byte{[]byte("hello")}, ignoreCase: true, lineNumber: true, byteOffset: true}, "1:0:Hello world\n3:20:hello HELLO\n"},
+		{options{needles: [][]byte{[]byte("world"), []byte("o b")}, only: true, byteOffset: true}, "6:world\n14:o b\n32:world\n37:world\n"},
+		{options{needles: [][]byte{[]byte("o")}, count: true, withName: true}, "f:4\n"},
+		{options{needles: [][]byte{[]byte("zz")}, count: true}, "0\n"},
+		{options{needles: [][]byte{[]byte("oo"), []byte("wor")}, withName: true}, "f:Hello world\nf:foo bar\nf:worldworld\n"},
+	} {
+		if tc.o.ignoreCase {
+			for i := range tc.o.needles {
+				tc.o.needles[i] = foldASCII(tc.o.needles[i])
+			}
+		}
+		var out bytes.Buffer
+		if _, e := grep("f", data, &tc.o, &out); e != nil || out.String() != tc.want {
+			t.Errorf("grep %+v = %q, %v; want %q", tc.o, out.String(), e, tc.want)
+		}
+	}
+}
+
+func TestGrep_Binary(t *testing.T) {
+	var out bytes.Buffer
+	o := options{needles: [][]byte{[]byte("x")}}
+	if matched, _ := grep("bin", []byte("\x00\x01x"), &o, &out); !matched || out.String() != "Binary file bin matches\n" {
+		t.Errorf("grep binary = %v, %q", matched, out.String())
+	}
+}
+
+// windows of a few lines give the same output as one window over the whole file
+func TestGrep_Windows(t *testing.T) {
+	var data []byte
+	rnd := rand.New(rand.NewSource(1))
+	for len(data) < 1<<14 {
+		for k := rnd.Intn(120); k > 0; k-- {
+			data = append(data, "abcAB C"[rnd.Intn(7)])
+		}
+		data = append(data, '\n')
+	}
+	data = append(data, "abc ab"...) // no newline at the end
+
+	needles := [][]byte{[]byte("abc"), []byte("b\nc"), []byte("ab ab"), []byte("c")}
+	for _, o := range []options{
+		{needles: needles[:1]},
+		{needles: needles, lineNumber: true, byteOffset: true},
+		{needles: needles, only: true, byteOffset: true, lineNumber: true},
+		{needles: needles[:3], ignoreCase: true, lineNumber: true},
+		{needles: needles[1:], count: true},
+	} {
+		var want bytes.Buffer
+		o.window = len(data) + 1
+		if _, e := grep("f", data, &o, &want); e != nil {
+			t.Fatal(e)
+		}
+		for _, w := range []int{1, 7, 64, 1000} {
+			var out bytes.Buffer
+			o.window = w
+			if _, e := grep("f", data, &o, &out); e != nil || out.String() != want.String() {
+				t.Fatalf("window %d, %+v: output differs (%d bytes, want %d), %v", w, o, out.Len(), want.Len(), e)
+			}
+		}
+	}
+}
+
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"b/x", "a/y", "a/c/z", "top"} {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if e := os.WriteFile(path, []byte(f), 0o644); e != nil {
+			t.Fatal(e)
+		}
+	}
+	files, errs := collectFiles([]string{dir, filepath.Join(dir, "top")}, true)
+	var got []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := "a/c/z a/y b/x top top"
+	if errs || strings.Join(got, " ") != want {
+		t.Errorf("collectFiles -r = %v, %v; want %s", got, errs, want)
+	}
+	if files, errs = collectFiles([]string{dir}, false); !errs || len(files) != 0 {
+		t.Errorf("collectFiles of a directory without -r = %v, %v", files, errs)
+	}
+	if files, errs = collectFiles(nil, false); errs || len(files) != 1 || files[0] != "-" {
+		t.Errorf("collectFiles without files = %v, %v", files, errs)
+	}
+}
+
+// the output of files searched in parallel comes in the order of the files
+func TestRun_Order(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		files []string
+		want  bytes.Buffer
+	)
+	for i := 0; i < 40; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("f%02d", i))
+		data := bytes.Repeat([]byte(fmt.Sprintf("line %d needle\n", i)), 1+(i*7919)%300)
+		if i == 3 {
+			// more output than a worker holds ahead of its turn
+			data = bytes.Repeat(data, 2*outBlocks*outBlock/len(data))
+		}
+		if e := os.WriteFile(path, data, 0o644); e != nil {
+			t.Fatal(e)
+		}
+		files = append(files, path)
+		want.Write(bytes.ReplaceAll(data, []byte("line"), []byte(path+":line")))
+	}
+	files = append(files, filepath.Join(dir, "missing"))
+	o := &options{needles: [][]byte{[]byte("needle")}, withName: true}
+	var out, errs bytes.Buffer
+	if status := run(files, o, 8, &out, &errs); status != 2 {
+		t.Errorf("status = %d; want 2 (missing file)", status)
+	}
+	if out.String() != want.String() {
+		t.Errorf("output out of order")
+	}
+	if !strings.Contains(errs.String(), "missing") {
+		t.Errorf("stderr = %q", errs.String())
+	}
+}
+
+func TestSplitFlags(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"-ni pat f", "-n -i pat f"},
+		{"-c -ie pat f", "-c -i -e pat f"},
+		{"-iepat -e x", "-i -e pat -e x"},
+		{"-e -ni f", "-e -ni f"},
+		{"-j=4 -rn pat", "-j=4 -r -n pat"},
+		{"-j 4 pat -ni", "-j 4 pat -ni"},
+		{"-- -ni", "-- -ni"},
+	} {
+		if got := strings.Join(splitFlags(strings.Fields(c.args)), " "); got != c.want {
+			t.Errorf("splitFlags(%s) = %s; want %s", c.args, got, c.want)
+		}
+	}
+}
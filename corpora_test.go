@@ -0,0 +1,49 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+func TestCorpora_Profiles(t *testing.T) {
+	for _, p := range append(Profiles(), Auto()) {
+		m, e := NewMatcher(p)
+		if e != nil {
+			t.Fatal(e)
+		}
+		t.Run(p.Name, func(t *testing.T) {
+			testcorpus.Check(t, m, 1, 0)
+		})
+	}
+}
+
+func TestCorpora_Memchr(t *testing.T) {
+	testcorpus.Check(t, memchr{}, 1, 1)
+}
+
+func TestCorpora_SIMD(t *testing.T) {
+	testcorpus.Check(t, simdSearcher{}, 2, simdMaxNeedle)
+}
@@ -0,0 +1,315 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// Corpus holds many named documents searched together: the needle is preprocessed
+// once per search and the documents are searched in parallel.
+// It is safe for concurrent use.
+type Corpus struct {
+	profile *Profile
+	mu      sync.RWMutex
+	names   []string
+	docs    [][]byte
+}
+
+// CorpusHit is a match in a document of a Corpus.
+type CorpusHit struct {
+	Doc    string // name of the document
+	Offset int    // index in the document
+}
+
+// CorpusOptions tune the searches of a Corpus; the zero value finds all hits in parallel.
+type CorpusOptions struct {
+	// Workers searching documents in parallel; 0: runtime.NumCPU()
+	Workers int
+	// MaxPerDoc > 0 stops the search of each document at its MaxPerDoc-th hit
+	MaxPerDoc int
+	// MaxHits > 0 stops the search after MaxHits hits
+	MaxHits int
+}
+
+// NewCorpus returns an empty Corpus searched with a copy of profile p.
+// Auto picks the built-in profile for a sample of all documents at each search.
+func NewCorpus(p *Profile) (*Corpus, error) {
+	if e := p.Validate(); e != nil {
+		return nil, e
+	}
+	return &Corpus{profile: p.clone()}, nil
+}
+
+// Add appends the document data named name; data is not copied and must not be changed.
+// Names need not be unique.
+func (c *Corpus) Add(name string, data []byte) {
+	c.mu.Lock()
+	c.names = append(c.names, name)
+	c.docs = append(c.docs, data)
+	c.mu.Unlock()
+}
+
+// Len returns the number of documents.
+func (c *Corpus) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.docs)
+}
+
+// Search calls fn for the hits of needle in document order (the order of Add),
+// ascending in each document, until fn returns false.
+func (c *Corpus) Search(needle []byte, opts *CorpusOptions, fn func(CorpusHit) bool) error {
+	return c.run(needle, opts, modeHits, func(name string, r docResult) bool {
+		for _, i := range r.hits {
+			if !fn(CorpusHit{name, i}) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// FindAll returns all hits of needle in document order.
+func (c *Corpus) FindAll(needle []byte, opts *CorpusOptions) (hits []CorpusHit, e error) {
+	e = c.Search(needle, opts, func(h CorpusHit) bool {
+		hits = append(hits, h)
+		return true
+	})
+	return hits, e
+}
+
+// Index returns the first hit of needle in each document holding it, in document order.
+func (c *Corpus) Index(needle []byte, opts *CorpusOptions) ([]CorpusHit, error) {
+	var o CorpusOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.MaxPerDoc = 1
+	return c.FindAll(needle, &o)
+}
+
+// Count returns the number of hits of needle in all documents.
+func (c *Corpus) Count(needle []byte, opts *CorpusOptions) (count int, e error) {
+	e = c.run(needle, opts, modeCount, func(_ string, r docResult) bool {
+		count += r.count
+		return true
+	})
+	return count, e
+}
+
+const (
+	modeHits = iota
+	modeCount
+)
+
+// docResult is the outcome of the search in one document
+type docResult struct {
+	hits  []int
+	count int
+	e     error
+}
+
+// run searches the documents in parallel and calls deliver for them in document order
+// (except those with MaxHits reached) until it returns false.
+// Workers run at most a few documents ahead of deliver.
+func (c *Corpus) run(needle []byte, opts *CorpusOptions, mode int, deliver func(string, docResult) bool) error {
+	var o CorpusOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Workers < 1 {
+		o.Workers = runtime.NumCPU()
+	}
+	if len(needle) < 1 {
+		return NEEDLESHORT
+	}
+	// no document contributes more than MaxHits hits
+	if o.MaxHits > 0 && (o.MaxPerDoc < 1 || o.MaxPerDoc > o.MaxHits) {
+		o.MaxPerDoc = o.MaxHits
+	}
+
+	c.mu.RLock()
+	names, docs := c.names, c.docs
+	c.mu.RUnlock()
+	if len(docs) == 0 {
+		return nil
+	}
+
+	a, e := c.profile.forHaystack(sampleDocs(docs)).pick(nil, len(needle))
+	if e != nil {
+		return e
+	}
+	cn, e := compile(a.Searcher, needle)
+	if e != nil {
+		return e
+	}
+
+	var (
+		results = make([]chan docResult, len(docs))
+		ahead   = make(chan struct{}, 4*o.Workers)
+		jobs    = make(chan int)
+		done    = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	for k := range results {
+		results[k] = make(chan docResult, 1)
+	}
+	go func() {
+		defer close(jobs)
+		for k := range docs {
+			select {
+			case ahead <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- k:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < o.Workers && w < len(docs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				results[k] <- searchDoc(cn, docs[k], len(needle), mode, o.MaxPerDoc)
+			}
+		}()
+	}
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	hits := 0
+	for k := range docs {
+		r := <-results[k]
+		<-ahead
+		if r.e != nil {
+			return r.e
+		}
+		if o.MaxHits > 0 {
+			if left := o.MaxHits - hits; len(r.hits) > left {
+				r.hits = r.hits[:left]
+			}
+			if r.count > o.MaxHits-hits {
+				r.count = o.MaxHits - hits
+			}
+			hits += len(r.hits) + r.count
+		}
+		if !deliver(names[k], r) || o.MaxHits > 0 && hits >= o.MaxHits {
+			return nil
+		}
+	}
+	return nil
+}
+
+// searchDoc searches one document; documents shorter than the needle hold no hits
+func searchDoc(cn registry.Compiled, doc []byte, m, mode, maxPerDoc int) (r docResult) {
+	if len(doc) < m {
+		return r
+	}
+	switch {
+	case maxPerDoc > 0:
+		var hits []int
+		if hits, r.e = firstHits(cn, doc, m, maxPerDoc); mode == modeCount {
+			r.count = len(hits)
+		} else {
+			r.hits = hits
+		}
+	case mode == modeCount:
+		r.count, r.e = cn.Count(&doc)
+	default:
+		r.hits, r.e = cn.FindAll(&doc)
+	}
+	return r
+}
+
+// firstHits returns the first limit hits of doc. It searches the rest of the document
+// after each hit, so it stops at the limit-th hit instead of searching all of doc.
+func firstHits(cn registry.Compiled, doc []byte, m, limit int) (hits []int, e error) {
+	for pos := 0; len(hits) < limit && len(doc)-pos >= m; {
+		rest := doc[pos:]
+		i, e := cn.Index(&rest)
+		if e != nil || i < 0 {
+			return hits, e
+		}
+		hits = append(hits, pos+i)
+		pos += i + 1
+	}
+	return hits, nil
+}
+
+// sampleDocs returns about autoSampleSize bytes taken evenly from the documents
+// for the Auto profile
+func sampleDocs(docs [][]byte) []byte {
+	chunk := autoSampleSize / len(docs)
+	if chunk < 64 {
+		chunk = 64
+	}
+	step := 1
+	if len(docs)*chunk > autoSampleSize {
+		step = len(docs) * chunk / autoSampleSize
+	}
+	sample := make([]byte, 0, autoSampleSize+chunk)
+	for k := 0; k < len(docs) && len(sample) < autoSampleSize; k += step {
+		d := docs[k]
+		if len(d) > chunk {
+			d = d[:chunk]
+		}
+		sample = append(sample, d...)
+	}
+	return sample
+}
+
+// compile preprocesses needle with s if s implements registry.Compiler
+func compile(s Searcher, needle []byte) (registry.Compiled, error) {
+	if c, ok := s.(registry.Compiler); ok {
+		return c.Compile(needle)
+	}
+	return &uncompiled{s, append([]byte(nil), needle...)}, nil
+}
+
+// uncompiled adapts a Searcher without preprocessing of its own to registry.Compiled
+type uncompiled struct {
+	s      Searcher
+	needle []byte
+}
+
+func (u *uncompiled) Index(haystack *[]byte) (int, error) {
+	return u.s.Index(haystack, &u.needle)
+}
+
+func (u *uncompiled) Count(haystack *[]byte) (int, error) {
+	return u.s.Count(haystack, &u.needle)
+}
+
+func (u *uncompiled) FindAll(haystack *[]byte) ([]int, error) {
+	return u.s.FindAll(haystack, &u.needle)
+}
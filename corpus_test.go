@@ -0,0 +1,217 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// corpusDocs returns a Corpus over slices of all test corpora and the expected hits of needle
+func corpusDocs(t *testing.T, p *Profile, needle []byte) (*Corpus, []CorpusHit) {
+	c, e := NewCorpus(p)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var want []CorpusHit
+	for _, tc := range testcorpus.All(1, 1<<12) {
+		for k := 0; k < len(tc.Data); k += 97 * (k%5 + 1) {
+			end := k + k%700 + 1
+			if end > len(tc.Data) {
+				end = len(tc.Data)
+			}
+			doc := tc.Data[k:end]
+			name := tc.Name + "/" + strconv.Itoa(k)
+			c.Add(name, doc)
+			for _, i := range testcorpus.FindAll(doc, needle) {
+				want = append(want, CorpusHit{name, i})
+			}
+		}
+	}
+	return c, want
+}
+
+func TestCorpus(t *testing.T) {
+	data := testcorpus.Text(1, 1<<12)
+	for _, p := range []*Profile{NaturalText(), Auto()} {
+		for _, m := range []int{1, 2, 3, 5, 9, 17, 80} {
+			for _, needle := range testcorpus.Needles(int64(m), data, m, 3) {
+				c, want := corpusDocs(t, p, needle)
+				for _, workers := range []int{1, 3, 0} {
+					o := &CorpusOptions{Workers: workers}
+					got, e := c.FindAll(needle, o)
+					if e != nil {
+						t.Fatal(e)
+					}
+					if !equalHits(got, want) {
+						t.Fatalf("%s: FindAll(%q) found %d hits; want %d", p.Name, needle, len(got), len(want))
+					}
+					count, e := c.Count(needle, o)
+					if e != nil || count != len(want) {
+						t.Fatalf("%s: Count(%q) = %d, %v; want %d", p.Name, needle, count, e, len(want))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCorpus_Limits(t *testing.T) {
+	needle := []byte("e")
+	c, all := corpusDocs(t, NaturalText(), needle)
+
+	var first []CorpusHit
+	perDoc := map[string]int{}
+	for _, h := range all {
+		if perDoc[h.Doc]++; perDoc[h.Doc] <= 2 {
+			first = append(first, h)
+		}
+	}
+	got, e := c.FindAll(needle, &CorpusOptions{MaxPerDoc: 2})
+	if e != nil || !equalHits(got, first) {
+		t.Fatalf("MaxPerDoc 2: found %d hits, %v; want %d", len(got), e, len(first))
+	}
+	count, e := c.Count(needle, &CorpusOptions{MaxPerDoc: 2})
+	if e != nil || count != len(first) {
+		t.Fatalf("MaxPerDoc 2: Count = %d, %v; want %d", count, e, len(first))
+	}
+
+	idx, e := c.Index(needle, nil)
+	if e != nil || len(idx) != len(perDoc) {
+		t.Fatalf("Index found %d documents, %v; want %d", len(idx), e, len(perDoc))
+	}
+	for _, h := range idx {
+		if h.Offset != testcorpus.FindAll(docByName(c, h.Doc), needle)[0] {
+			t.Fatalf("Index: %v is not the first hit", h)
+		}
+	}
+
+	got, e = c.FindAll(needle, &CorpusOptions{MaxHits: 10})
+	if e != nil || !equalHits(got, all[:10]) {
+		t.Fatalf("MaxHits 10: found %v, %v; want %v", got, e, all[:10])
+	}
+	if count, _ = c.Count(needle, &CorpusOptions{MaxHits: 10}); count != 10 {
+		t.Fatalf("MaxHits 10: Count = %d", count)
+	}
+
+	// early termination by the callback
+	n := 0
+	e = c.Search(needle, &CorpusOptions{Workers: 4}, func(h CorpusHit) bool {
+		n++
+		return n < 7
+	})
+	if e != nil || n != 7 {
+		t.Fatalf("Search stopped after %d hits, %v; want 7", n, e)
+	}
+}
+
+// indexOnly fails the searches of whole documents
+type indexOnly struct {
+	registry.Compiled
+	calls int
+}
+
+func (s *indexOnly) Index(haystack *[]byte) (int, error) {
+	s.calls++
+	return s.Compiled.Index(haystack)
+}
+
+func (s *indexOnly) Count(*[]byte) (int, error)     { panic("Count searches the whole document") }
+func (s *indexOnly) FindAll(*[]byte) ([]int, error) { panic("FindAll searches the whole document") }
+
+// TestCorpus_MaxPerDocStops checks MaxPerDoc stops the search of a document at its last hit
+func TestCorpus_MaxPerDocStops(t *testing.T) {
+	doc := testcorpus.Text(1, 1<<16)
+	needle := []byte("e")
+	want := testcorpus.FindAll(doc, needle)[:3]
+	a, _ := naturalTextProfile.pick(nil, len(needle))
+	cn, _ := compile(a.Searcher, needle)
+	for _, mode := range []int{modeHits, modeCount} {
+		spy := &indexOnly{Compiled: cn}
+		r := searchDoc(spy, doc, len(needle), mode, 3)
+		if r.e != nil || spy.calls != 3 || mode == modeCount && r.count != 3 || mode == modeHits && !reflect.DeepEqual(r.hits, want) {
+			t.Fatalf("mode %d: %d searches, hits %v, count %d, %v; want 3 searches, hits %v", mode, spy.calls, r.hits, r.count, r.e, want)
+		}
+	}
+}
+
+func TestCorpus_Errors(t *testing.T) {
+	if _, e := NewCorpus(nil); e != NOPROFILE {
+		t.Fatalf("NewCorpus(nil): %v", e)
+	}
+	c, _ := NewCorpus(NaturalText())
+	if hits, e := c.FindAll([]byte("abc"), nil); e != nil || hits != nil {
+		t.Fatalf("empty corpus: %v, %v", hits, e)
+	}
+	c.Add("short", []byte("ab"))
+	c.Add("doc", []byte("xxabcxx"))
+	if _, e := c.FindAll(nil, nil); e != NEEDLESHORT {
+		t.Fatalf("empty needle: %v", e)
+	}
+	hits, e := c.FindAll([]byte("abc"), nil)
+	if e != nil || !equalHits(hits, []CorpusHit{{"doc", 2}}) {
+		t.Fatalf("FindAll = %v, %v", hits, e)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d", c.Len())
+	}
+}
+
+func docByName(c *Corpus, name string) []byte {
+	for k, n := range c.names {
+		if n == name {
+			return c.docs[k]
+		}
+	}
+	return nil
+}
+
+func equalHits(a, b []CorpusHit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func BenchmarkCorpus(b *testing.B) {
+	c, _ := NewCorpus(NaturalText())
+	data := testcorpus.Text(1, 1<<22)
+	for k := 0; k+4096 <= len(data); k += 4096 {
+		c.Add(strconv.Itoa(k), data[k:k+4096])
+	}
+	needle := testcorpus.Needles(1, data, 120, 1)[0]
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Count(needle, nil)
+	}
+}
@@ -0,0 +1,167 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/AndreasBriese/bmatch/registry"
+
+	// the algorithm packages register themselves
+	_ "github.com/AndreasBriese/bmatch/bcjsearch"
+	_ "github.com/AndreasBriese/bmatch/bh2search"
+	_ "github.com/AndreasBriese/bmatch/bhsearch"
+	_ "github.com/AndreasBriese/bmatch/bs_fsbndm"
+)
+
+// Band routes all needles shorter than Below to the algorithm registered as Algorithm.
+// A Below of 0 marks the last band taking all longer needles.
+type Band struct {
+	Below     int    `json:"below"`
+	Algorithm string `json:"algorithm"`
+}
+
+// Profile is a dispatch table: the switch points between the algorithms
+// and the algorithm to use for each band of needle lengths.
+// The bands must be in ascending order of Below, cover all needle lengths from 1
+// and name registered algorithms that accept every needle length of their band.
+// The built-in profiles NaturalText, DNA, Protein and Binary return copies (see profiles.go).
+// A profile is used per call (IndexWith, CountWith, FindAllWith), per Matcher
+// or as the process default (SetDefaultProfile).
+type Profile struct {
+	Name  string `json:"name"`
+	Bands []Band `json:"bands"`
+}
+
+// Errors
+var (
+	NOPROFILE   = errors.New("profile is nil")
+	NOBANDS     = errors.New("dispatch table is empty")
+	BANDORDER   = errors.New("dispatch bands are not in ascending order")
+	BANDOPENEND = errors.New("last dispatch band must have Below == 0")
+)
+
+// defaultProfile holds the *Profile used by Index, Count and FindAll
+var defaultProfile atomic.Value
+
+func init() {
+	registry.MustRegister(memchrAlgorithm)
+	registry.MustRegister(simdAlgorithm)
+	registry.MustRegister(epsmAlgorithm)
+	if e := SetDefaultProfile(naturalTextProfile); e != nil {
+		panic("bmatch: " + e.Error())
+	}
+}
+
+// Validate checks the profile's bands against the registry.
+func (p *Profile) Validate() error {
+	if p == nil {
+		return NOPROFILE
+	}
+	if p.isAuto() {
+		return nil
+	}
+	if len(p.Bands) == 0 {
+		return NOBANDS
+	}
+	lo := 1
+	for i, b := range p.Bands {
+		last := i == len(p.Bands)-1
+		switch {
+		case last && b.Below != 0:
+			return BANDOPENEND
+		case !last && b.Below <= lo:
+			return BANDORDER
+		}
+		a, e := registry.Lookup(b.Algorithm)
+		if e != nil {
+			return errors.New(b.Algorithm + ": " + e.Error())
+		}
+		hi := b.Below - 1
+		if last {
+			hi = 0
+		}
+		if a.MinNeedle > lo || (a.MaxNeedle != 0 && (hi == 0 || a.MaxNeedle < hi)) {
+			return errors.New(b.Algorithm + " does not accept all needle lengths of band below " + strconv.Itoa(b.Below))
+		}
+		lo = b.Below
+	}
+	return nil
+}
+
+// clone returns a deep copy of the profile
+func (p *Profile) clone() *Profile {
+	c := &Profile{Name: p.Name, Bands: make([]Band, len(p.Bands))}
+	copy(c.Bands, p.Bands)
+	return c
+}
+
+// pick returns the algorithm for a needle of length m
+func (p *Profile) pick(haystack []byte, m int) (Algorithm, error) {
+	for _, b := range p.forHaystack(haystack).Bands {
+		if m < b.Below || b.Below == 0 {
+			a, e := registry.Lookup(b.Algorithm)
+			if e != nil {
+				return a, errors.New(b.Algorithm + ": " + e.Error())
+			}
+			if !a.Accepts(m) {
+				return a, errors.New(b.Algorithm + " does not accept needle length " + strconv.Itoa(m))
+			}
+			return a, nil
+		}
+	}
+	return Algorithm{}, NOBANDS
+}
+
+// SetDefaultProfile sets the profile used by Index, Count and FindAll.
+// The profile is copied; changing it afterwards has no effect.
+func SetDefaultProfile(p *Profile) error {
+	if e := p.Validate(); e != nil {
+		return e
+	}
+	defaultProfile.Store(p.clone())
+	return nil
+}
+
+// DefaultProfile returns a copy of the profile used by Index, Count and FindAll.
+func DefaultProfile() *Profile {
+	return defaultProfile.Load().(*Profile).clone()
+}
+
+// SetDispatch replaces the default profile by one made of the given bands,
+// i.e. to route a band of needle lengths to an algorithm of your own.
+func SetDispatch(bands ...Band) error {
+	return SetDefaultProfile(&Profile{Name: "custom", Bands: bands})
+}
+
+// Dispatch returns a copy of the bands of the default profile.
+func Dispatch() []Band {
+	return DefaultProfile().Bands
+}
+
+// pick returns the algorithm of the default profile for a needle of length m
+func pick(haystack []byte, m int) (Algorithm, error) {
+	return defaultProfile.Load().(*Profile).pick(haystack, m)
+}
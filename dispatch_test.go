@@ -0,0 +1,184 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// inHouse is a naive algorithm counting its calls
+type inHouse struct{ calls int }
+
+func (s *inHouse) Index(haystack, needle *[]byte) (int, error) {
+	s.calls++
+	return bytes.Index(*haystack, *needle), nil
+}
+
+func (s *inHouse) Count(haystack, needle *[]byte) (int, error) {
+	s.calls++
+	return bytesIndexCount(haystack, needle)
+}
+
+func (s *inHouse) FindAll(haystack, needle *[]byte) ([]int, error) {
+	s.calls++
+	return bytesIndexFindAll(haystack, needle)
+}
+
+func TestDispatch_RouteToRegistered(t *testing.T) {
+	s := &inHouse{}
+	if e := Register(Algorithm{Name: "test-inhouse", MinNeedle: 1, Searcher: s}); e != nil {
+		t.Fatal(e)
+	}
+	defer func() {
+		if e := Unregister("test-inhouse"); e != nil {
+			t.Error(e)
+		}
+		if e := Unregister("test-inhouse"); e != registry.UNREGISTERED {
+			t.Errorf("second Unregister = %v; want %v", e, registry.UNREGISTERED)
+		}
+	}()
+	if e := Register(Algorithm{Name: "test-inhouse", MinNeedle: 1, Searcher: s}); e != registry.DUPLICATE {
+		t.Errorf("second Register = %v; want %v", e, registry.DUPLICATE)
+	}
+
+	saved := defaultProfile.Load()
+	defer defaultProfile.Store(saved)
+
+	if e := SetDispatch(Band{2, "memchr"}, Band{10, "test-inhouse"}, Band{0, "bs_fsbndm"}); e != nil {
+		t.Fatal(e)
+	}
+
+	haystack := []byte("abracadabra!")
+	short, long := []byte("abra"), []byte("abracadabra")
+	if r, _ := FindAll(&haystack, &short); len(r) != 2 || r[0] != 0 || r[1] != 7 {
+		t.Errorf("FindAll = %v; want [0 7]", r)
+	}
+	if r, _ := Count(&haystack, &long); r != 1 {
+		t.Errorf("Count = %v; want 1", r)
+	}
+	if s.calls != 1 {
+		t.Errorf("in-house algorithm called %v times; want 1", s.calls)
+	}
+}
+
+func TestDispatch_Invalid(t *testing.T) {
+	for _, bands := range [][]Band{
+		{},
+		{{2, "memchr"}, {50, "bs_fsbndm"}}, // no open end
+		{{2, "memchr"}, {2, "bs_fsbndm"}, {0, "bs_fsbndm"}}, // not ascending
+		{{2, "memchr"}, {0, "not-registered"}},
+		{{3, "memchr"}, {0, "bs_fsbndm"}}, // memchr takes 1 byte needles only
+		{{2, "memchr"}, {0, "bhsearch"}},  // bhsearch needs 3 bytes
+		{{0, "bs_fsbndm"}},                // bs_fsbndm needs 2 bytes
+	} {
+		if e := SetDispatch(bands...); e == nil {
+			t.Errorf("SetDispatch(%v) accepted", bands)
+		}
+	}
+}
+
+func TestProfiles_Builtin(t *testing.T) {
+	for _, p := range Profiles() {
+		if e := p.Validate(); e != nil {
+			t.Errorf("%v: %v", p.Name, e)
+		}
+		// the built-in profiles hand out copies
+		p.Bands[0].Algorithm = "bcjsearch"
+	}
+	for _, p := range append(Profiles(), ProfileFor(AnalyzeAlphabet(nil))) {
+		if p.Bands[0].Algorithm != "memchr" {
+			t.Errorf("%v: built-in profile changed through a copy: %v", p.Name, p.Bands)
+		}
+	}
+}
+
+func TestProfiles_PerCallMatcherDefault(t *testing.T) {
+	makeRandomPatterns(64)
+
+	saved := defaultProfile.Load()
+	defer defaultProfile.Store(saved)
+
+	for _, p := range []*Profile{NaturalText(), DNA(), Binary()} {
+		mt, e := NewMatcher(p)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if e := SetDefaultProfile(p); e != nil {
+			t.Fatal(e)
+		}
+		for i := range pat {
+			want, _ := bytesIndexCount(&hay, &(pat[i]))
+			c1, _ := CountWith(p, &hay, &(pat[i]))
+			c2, _ := mt.Count(&hay, &(pat[i]))
+			c3, _ := Count(&hay, &(pat[i]))
+			if c1 != want || c2 != want || c3 != want {
+				t.Errorf("%v: Count %q = %v, %v, %v; want %v", p.Name, pat[i], c1, c2, c3, want)
+				break
+			}
+		}
+	}
+}
+
+// BenchmarkCrossover counts needles of the short bands of the built-in profiles with the
+// algorithms of the profile and those routed there before (bs_fsbndm, bhsearch) on data
+// of the profile's kind: go test -run XX -bench Crossover
+func BenchmarkCrossover(b *testing.B) {
+	for _, c := range []struct {
+		p    *Profile
+		data []byte
+	}{
+		{naturalTextProfile, testcorpus.Text(1, 1<<20)},
+		{dnaProfile, testcorpus.DNA(1, 1<<20)},
+		{proteinProfile, testcorpus.Protein(1, 1<<20)},
+		{binaryProfile, testcorpus.Binary(1, 1<<20)},
+	} {
+		names := []string{"bs_fsbndm", "bhsearch"}
+		for _, band := range c.p.Bands {
+			names = append(names, band.Algorithm)
+		}
+		for _, m := range []int{2, 3, 4, 6, 8, 12, 16, 24, 32, 48, 64, 96} {
+			needles := testcorpus.Needles(int64(m), c.data, m, 16)
+			done := map[string]bool{}
+			for _, name := range names {
+				a, e := registry.Lookup(name)
+				if e != nil || done[name] || !a.Accepts(m) || a.Name == "memchr" {
+					continue
+				}
+				done[name] = true
+				b.Run(c.p.Name+"/"+strconv.Itoa(m)+"/"+name, func(b *testing.B) {
+					b.SetBytes(int64(len(c.data) * len(needles)))
+					for i := 0; i < b.N; i++ {
+						for k := range needles {
+							a.Searcher.Count(&c.data, &needles[k])
+						}
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	bsf "github.com/AndreasBriese/bmatch/bs_fsbndm"
+)
+
+// NMode tells how an N (unknown base) in the genome compares to a pattern.
+type NMode int
+
+const (
+	NMismatch NMode = iota // N matches no pattern position
+	NMatch                 // N matches pattern positions holding N
+	NWildcard              // N matches every pattern position
+)
+
+// bases of the IUPAC nucleotide codes
+var iupac = map[byte]string{
+	'A': "A", 'C': "C", 'G': "G", 'T': "T", 'U': "T",
+	'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+	'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG",
+	'N': "ACGT",
+}
+
+// Pattern is a nucleotide needle compiled to one byte class per position.
+type Pattern struct {
+	classes []bsf.Class
+}
+
+// CompileIUPAC compiles needle, given in IUPAC nucleotide codes (either case), for the genome's N
+// handled as mode. Bases compare case insensitive.
+func CompileIUPAC(needle []byte, mode NMode) (*Pattern, error) {
+	if len(needle) < 1 {
+		return nil, NEEDLESHORT
+	}
+	p := &Pattern{make([]bsf.Class, len(needle))}
+	for i, c := range needle {
+		code := c &^ 0x20 // upper case
+		bases, ok := iupac[code]
+		if !ok {
+			return nil, INVALIDBASE
+		}
+		for _, b := range []byte(bases) {
+			p.classes[i].Add(b, b|0x20)
+		}
+		if mode == NWildcard || mode == NMatch && code == 'N' {
+			p.classes[i].Add('N', 'n')
+		}
+	}
+	return p, nil
+}
+
+// Len returns the length of the pattern.
+func (p *Pattern) Len() int {
+	return len(p.classes)
+}
+
+// Index returns the offset of the first match in genome or -1.
+func (p *Pattern) Index(genome []byte) (int, error) {
+	if len(genome) < len(p.classes) {
+		return -1, nil
+	}
+	if len(p.classes) == 1 {
+		for i, c := range genome {
+			if p.classes[0].Has(c) {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}
+	return bsf.IndexClasses(&genome, p.classes)
+}
+
+// Count returns the number of (overlapping) matches in genome.
+func (p *Pattern) Count(genome []byte) (int, error) {
+	if len(genome) < len(p.classes) {
+		return 0, nil
+	}
+	if len(p.classes) == 1 {
+		found, _ := p.FindAll(genome)
+		return len(found), nil
+	}
+	return bsf.CountClasses(&genome, p.classes)
+}
+
+// FindAll returns the offsets of all (overlapping) matches in genome.
+func (p *Pattern) FindAll(genome []byte) (found []int, e error) {
+	if len(genome) < len(p.classes) {
+		return nil, nil
+	}
+	if len(p.classes) == 1 {
+		for i, c := range genome {
+			if p.classes[0].Has(c) {
+				found = append(found, i)
+			}
+		}
+		return found, nil
+	}
+	return bsf.FindAllClasses(&genome, p.classes)
+}
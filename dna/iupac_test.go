@@ -0,0 +1,117 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// referenceIUPAC compares window by window
+func referenceIUPAC(genome, needle []byte, mode NMode) (found []int) {
+	for i := 0; i+len(needle) <= len(genome); i++ {
+		j := 0
+		for ; j < len(needle); j++ {
+			g, c := genome[i+j]&^0x20, needle[j]&^0x20
+			if g == 'N' {
+				if mode == NWildcard || mode == NMatch && c == 'N' {
+					continue
+				}
+				break
+			}
+			if !strings.ContainsRune(iupac[c], rune(g)) {
+				break
+			}
+		}
+		if j == len(needle) {
+			found = append(found, i)
+		}
+	}
+	return found
+}
+
+func TestCompileIUPAC(t *testing.T) {
+	for _, needle := range []string{"", "ACGX", "AC-T"} {
+		if _, e := CompileIUPAC([]byte(needle), NMismatch); e == nil {
+			t.Fatalf("CompileIUPAC(%q) should fail", needle)
+		}
+	}
+	p, e := CompileIUPAC([]byte("TATAWAWR"), NMismatch)
+	if e != nil {
+		t.Fatal(e)
+	}
+	found, _ := p.FindAll([]byte("xxTATAAATGtataTaAGNN"))
+	if len(found) != 2 || found[0] != 2 || found[1] != 10 {
+		t.Fatalf("FindAll = %v", found)
+	}
+}
+
+func TestPattern_Search(t *testing.T) {
+	r := rand.New(rand.NewSource(34))
+	genome := randomSeq(r, 30000)
+	for i := 0; i < 3000; i++ {
+		genome[r.Intn(len(genome))] = "acgtNn"[r.Intn(6)]
+	}
+	codes := []byte("ACGTURYSWKMBDHVNacgtn")
+	for m := 1; m <= 90; m++ {
+		needle := append([]byte{}, genome[m*100:m*101]...)
+		for i := range needle {
+			if r.Intn(3) == 0 {
+				needle[i] = codes[r.Intn(len(codes))]
+			}
+			if needle[i]&^0x20 == 'N' && r.Intn(2) == 0 {
+				needle[i] = 'A'
+			}
+		}
+		for _, mode := range []NMode{NMismatch, NMatch, NWildcard} {
+			p, e := CompileIUPAC(needle, mode)
+			if e != nil {
+				t.Fatal(e)
+			}
+			want := referenceIUPAC(genome, needle, mode)
+			got, _ := p.FindAll(genome)
+			if len(got) != len(want) {
+				t.Fatalf("mode %d FindAll(%s) = %v; want %v", mode, needle, got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("mode %d FindAll(%s) = %v; want %v", mode, needle, got, want)
+				}
+			}
+			if c, _ := p.Count(genome); c != len(want) {
+				t.Fatalf("mode %d Count(%s) = %d; want %d", mode, needle, c, len(want))
+			}
+			idx, _ := p.Index(genome)
+			if len(want) > 0 && idx != want[0] || len(want) == 0 && idx != -1 {
+				t.Fatalf("mode %d Index(%s) = %d; want %v", mode, needle, idx, want)
+			}
+		}
+	}
+	// whole genome as needle
+	p, _ := CompileIUPAC(bytes.Replace(genome, []byte("N"), []byte("A"), -1), NWildcard)
+	if c, _ := p.Count(genome); c != 1 {
+		t.Fatalf("Count(genome) = %d", c)
+	}
+}
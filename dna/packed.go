@@ -0,0 +1,277 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * dna is the home of bmatch's searches specific to nucleotide sequences.
+ *
+ * Packed sequences store four bases per byte, two bits each: A = 00, C = 01, G = 10, T = 11,
+ * the first base in the most significant bits. Packed.Index, Count and FindAll search them
+ * without unpacking and return base offsets.
+ * A needle may start at any of the four bases (phases) of a byte. For each phase the bases of
+ * the needle that fill whole bytes (the core) are searched with bs_fsbndm directly in the packed
+ * bytes; the core's candidates are completed by comparing the partial bytes before and after it.
+ * Needles too short to have a core of two bytes in every phase (< 11 bases) are compared
+ * against a sliding 64 bit word of packed bases.
+ *
+ * FindAllBothStrands searches plain sequences for a needle and its reverse complement in one pass.
+ * SearchRecords streams FASTA and FASTQ files and finds needles across their line breaks.
+ * CompileIUPAC turns needles in IUPAC codes into bs_fsbndm class patterns.
+ * PWM scans for windows scoring above a threshold on a position weight matrix.
+ */
+
+package dna
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/AndreasBriese/bmatch/alphabet"
+	bsf "github.com/AndreasBriese/bmatch/bs_fsbndm"
+)
+
+// Errors
+var (
+	INVALIDBASE = errors.New("sequence contains a base other than A, C, G, T")
+	NEEDLESHORT = errors.New("Length needle is < 1")
+	SHORTDATA   = errors.New("packed data is shorter than the number of bases")
+)
+
+// Packed is a 2 bit packed nucleotide sequence of Len bases.
+type Packed struct {
+	Data []byte
+	Len  int
+}
+
+// NewPacked wraps data holding n packed bases.
+func NewPacked(data []byte, n int) (*Packed, error) {
+	if n < 0 || len(data) < (n+3)/4 {
+		return nil, SHORTDATA
+	}
+	return &Packed{data, n}, nil
+}
+
+// Pack packs the sequence seq of the bases ACGT (either case).
+func Pack(seq []byte) (*Packed, error) {
+	data := make([]byte, (len(seq)+3)/4)
+	if e := packInto(data, seq); e != nil {
+		return nil, e
+	}
+	return &Packed{data, len(seq)}, nil
+}
+
+// packInto packs seq into dst, which holds at least (len(seq)+3)/4 bytes
+func packInto(dst, seq []byte) error {
+	for i, c := range seq {
+		if !isBase(c) {
+			return INVALIDBASE
+		}
+		dst[i>>2] |= alphabet.DNA.Code(c) << (6 - 2*uint(i&3))
+	}
+	return nil
+}
+
+// isBase reports whether c is one of ACGTacgt
+func isBase(c byte) bool {
+	switch c {
+	case 'A', 'C', 'G', 'T', 'a', 'c', 'g', 't':
+		return true
+	}
+	return false
+}
+
+// Base returns the code (0..3) of base i.
+func (p *Packed) Base(i int) byte {
+	return p.Data[i>>2] >> (6 - 2*uint(i&3)) & 3
+}
+
+// Unpack returns the sequence as upper case letters.
+func (p *Packed) Unpack() []byte {
+	seq := make([]byte, p.Len)
+	for i := range seq {
+		seq[i] = "ACGT"[p.Base(i)]
+	}
+	return seq
+}
+
+// Index returns the base offset of the first occurrence of needle (ACGT letters) or -1.
+func (p *Packed) Index(needle []byte) (int, error) {
+	found, e := p.search(needle, true)
+	if e != nil || len(found) == 0 {
+		return -1, e
+	}
+	return found[0], nil
+}
+
+// Count returns the number of (overlapping) occurrences of needle.
+func (p *Packed) Count(needle []byte) (int, error) {
+	found, e := p.search(needle, false)
+	if e != nil {
+		return -1, e
+	}
+	return len(found), nil
+}
+
+// FindAll returns the base offsets of all (overlapping) occurrences of needle in ascending order.
+func (p *Packed) FindAll(needle []byte) ([]int, error) {
+	return p.search(needle, false)
+}
+
+// shortest needle searched by its core
+const minCore = 11
+
+func (p *Packed) search(needle []byte, first bool) ([]int, error) {
+	m := len(needle)
+	if m < 1 {
+		return nil, NEEDLESHORT
+	}
+	for _, c := range needle {
+		if !isBase(c) {
+			return nil, INVALIDBASE
+		}
+	}
+	if m > p.Len {
+		return nil, nil
+	}
+	if m < minCore {
+		return p.searchWord(needle, first), nil
+	}
+
+	var found []int
+	best := p.Len
+	for phase := 0; phase < 4; phase++ {
+		ph := newPhase(needle, phase)
+		if first {
+			if idx := p.firstInPhase(ph, best); idx < best {
+				best = idx
+			}
+			continue
+		}
+		found = append(found, p.allInPhase(ph)...)
+	}
+	if first {
+		if best < p.Len {
+			found = append(found, best)
+		}
+		return found, nil
+	}
+	sort.Ints(found)
+	return found, nil
+}
+
+// phase holds the needle split for occurrences starting at base offset phase (mod 4)
+type phase struct {
+	m        int
+	head     int  // bases before the core
+	headByte byte // in the low 2*head bits
+	headMask byte
+	core     []byte // packed whole bytes
+	tail     int    // bases after the core
+	tailByte byte   // in the high 2*tail bits
+	tailMask byte
+}
+
+func newPhase(needle []byte, offset int) *phase {
+	ph := &phase{m: len(needle), head: (4 - offset) & 3}
+	for i := 0; i < ph.head; i++ {
+		ph.headByte = ph.headByte<<2 | alphabet.DNA.Code(needle[i])
+	}
+	ph.headMask = byte(1)<<(2*uint(ph.head)) - 1
+	n := (ph.m - ph.head) / 4
+	ph.core = make([]byte, n)
+	packInto(ph.core, needle[ph.head:ph.head+4*n])
+	ph.tail = ph.m - ph.head - 4*n
+	for i := 0; i < ph.tail; i++ {
+		ph.tailByte |= alphabet.DNA.Code(needle[ph.head+4*n+i]) << (6 - 2*uint(i))
+	}
+	ph.tailMask = ^(byte(0xff) >> (2 * uint(ph.tail)))
+	return ph
+}
+
+// verify checks the partial bytes around a core found at byte b and
+// returns the base offset of the occurrence or -1
+func (p *Packed) verify(ph *phase, b int) int {
+	s := 4*b - ph.head
+	if s < 0 || s+ph.m > p.Len {
+		return -1
+	}
+	if ph.head > 0 && p.Data[b-1]&ph.headMask != ph.headByte {
+		return -1
+	}
+	if ph.tail > 0 && p.Data[b+len(ph.core)]&ph.tailMask != ph.tailByte {
+		return -1
+	}
+	return s
+}
+
+// bytes searcher for the cores
+var coreSearcher = bsf.WithAlphabet(alphabet.Bytes)
+
+func (p *Packed) allInPhase(ph *phase) (found []int) {
+	data := p.Data[:(p.Len+3)/4]
+	candidates, _ := coreSearcher.FindAll(&data, &ph.core)
+	for _, b := range candidates {
+		if s := p.verify(ph, b); s >= 0 {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// firstInPhase returns the first occurrence in phase ph before limit or limit
+func (p *Packed) firstInPhase(ph *phase, limit int) int {
+	data := p.Data[:(p.Len+3)/4]
+	for off := 0; off+len(ph.core) <= len(data); {
+		rest := data[off:]
+		b, _ := coreSearcher.Index(&rest, &ph.core)
+		if b < 0 || 4*(off+b)-ph.head >= limit {
+			return limit
+		}
+		if s := p.verify(ph, off+b); s >= 0 {
+			return s
+		}
+		off += b + 1
+	}
+	return limit
+}
+
+// searchWord slides a word of the last m packed bases over the sequence (m <= 32)
+func (p *Packed) searchWord(needle []byte, first bool) (found []int) {
+	var (
+		m    = len(needle)
+		mask = uint64(1)<<(2*uint(m)) - 1
+		pat  uint64
+		w    uint64
+	)
+	for _, c := range needle {
+		pat = pat<<2 | uint64(alphabet.DNA.Code(c))
+	}
+	for i := 0; i < p.Len; i++ {
+		w = (w<<2 | uint64(p.Base(i))) & mask
+		if i >= m-1 && w == pat {
+			found = append(found, i-m+1)
+			if first {
+				return found
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,110 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func randomSeq(r *rand.Rand, n int) []byte {
+	seq := make([]byte, n)
+	for i := range seq {
+		seq[i] = "ACGT"[r.Intn(4)]
+	}
+	return seq
+}
+
+func referenceAll(hay, needle []byte) (found []int) {
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		if bytes.Equal(hay[i:i+len(needle)], needle) {
+			found = append(found, i)
+		}
+	}
+	return found
+}
+
+func TestPack(t *testing.T) {
+	p, e := Pack([]byte("ACGTacgtA"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(p.Data, []byte{0x1b, 0x1b, 0x00}) || p.Len != 9 {
+		t.Fatalf("Pack: % x len %d", p.Data, p.Len)
+	}
+	if string(p.Unpack()) != "ACGTACGTA" {
+		t.Fatalf("Unpack: %s", p.Unpack())
+	}
+	if _, e := Pack([]byte("ACGN")); e != INVALIDBASE {
+		t.Fatalf("Pack N: %v", e)
+	}
+	if _, e := NewPacked([]byte{0}, 5); e != SHORTDATA {
+		t.Fatalf("NewPacked: %v", e)
+	}
+}
+
+func TestPacked_Search(t *testing.T) {
+	r := rand.New(rand.NewSource(31))
+	for _, n := range []int{1, 7, 64, 1001, 20000} {
+		seq := randomSeq(r, n)
+		p, _ := Pack(seq)
+		for m := 1; m <= 80 && m <= n; m++ {
+			// needles taken from every phase and one random needle
+			needles := [][]byte{randomSeq(r, m)}
+			for s := 0; s < 4 && s+m <= n; s++ {
+				needles = append(needles, seq[n-m-s:n-s], seq[s:s+m])
+			}
+			for _, needle := range needles {
+				want := referenceAll(seq, needle)
+				got, e := p.FindAll(needle)
+				if e != nil || len(got) != len(want) {
+					t.Fatalf("n=%d FindAll(%s) = %v, %v; want %v", n, needle, got, e, want)
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Fatalf("n=%d FindAll(%s) = %v; want %v", n, needle, got, want)
+					}
+				}
+				if c, _ := p.Count(needle); c != len(want) {
+					t.Fatalf("n=%d Count(%s) = %d; want %d", n, needle, c, len(want))
+				}
+				idx, _ := p.Index(needle)
+				if (len(want) == 0 && idx != -1) || (len(want) > 0 && idx != want[0]) {
+					t.Fatalf("n=%d Index(%s) = %d; want %v", n, needle, idx, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkPacked_FindAll(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	seq := randomSeq(r, 1<<22)
+	p, _ := Pack(seq)
+	needle := seq[1<<21+3 : 1<<21+3+32]
+	b.SetBytes(int64(len(p.Data)))
+	for i := 0; i < b.N; i++ {
+		p.FindAll(needle)
+	}
+}
@@ -0,0 +1,297 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/AndreasBriese/bmatch"
+)
+
+// Errors of PWM
+var (
+	EMPTYPWM    = errors.New("position weight matrix has no positions")
+	BADWEIGHT   = errors.New("position weight matrix holds NaN or +Inf")
+	BADCOUNTS   = errors.New("counts, background or pseudocount must be positive")
+	CORETOOLONG = errors.New("prefilter core is longer than the matrix")
+)
+
+// PWM is a log-odds position weight matrix: weights[i][b] scores base b (A, C, G, T) at position i.
+// It is immutable once built; table, order and bound are derived from the weights.
+type PWM struct {
+	weights [][4]float64
+	table   [][256]float64 // scores by byte; -Inf for bytes other than ACGT
+	order   []int          // positions by decreasing spread of their scores
+	bound   []float64      // bound[k]: best score of the positions order[k:]
+}
+
+// PWMHit is a window scoring at least the threshold of a scan.
+type PWMHit struct {
+	Offset int
+	Score  float64
+}
+
+// NewPWM returns the matrix of the log-odds weights.
+func NewPWM(weights [][4]float64) (*PWM, error) {
+	m := len(weights)
+	if m == 0 {
+		return nil, EMPTYPWM
+	}
+	p := &PWM{
+		weights: append([][4]float64(nil), weights...),
+		table:   make([][256]float64, m),
+		order:   make([]int, m),
+		bound:   make([]float64, m+1),
+	}
+	spread := make([]float64, m)
+	for i, w := range weights {
+		for c := range p.table[i] {
+			p.table[i][c] = math.Inf(-1)
+		}
+		lo, hi := w[0], w[0]
+		for b, s := range w {
+			if math.IsNaN(s) || math.IsInf(s, 1) {
+				return nil, BADWEIGHT
+			}
+			p.table[i]["ACGT"[b]] = s
+			p.table[i]["acgt"[b]] = s
+			lo, hi = math.Min(lo, s), math.Max(hi, s)
+		}
+		spread[i] = hi - lo
+		p.order[i] = i
+	}
+	// lookahead: the most selective positions first prune most windows early
+	sort.SliceStable(p.order, func(a, b int) bool { return spread[p.order[a]] > spread[p.order[b]] })
+	for k := m - 1; k >= 0; k-- {
+		p.bound[k] = p.bound[k+1] + p.max(p.order[k])
+	}
+	return p, nil
+}
+
+// PWMFromCounts returns the log-odds matrix (natural log) of base counts per position against the
+// background frequencies of A, C, G, T, adding pseudocount to every count.
+func PWMFromCounts(counts [][4]float64, background [4]float64, pseudocount float64) (*PWM, error) {
+	weights := make([][4]float64, len(counts))
+	for i, c := range counts {
+		total := 4 * pseudocount
+		for _, x := range c {
+			total += x
+		}
+		for b, x := range c {
+			if x < 0 || background[b] <= 0 || x+pseudocount <= 0 {
+				return nil, BADCOUNTS
+			}
+			weights[i][b] = math.Log((x + pseudocount) / total / background[b])
+		}
+	}
+	return NewPWM(weights)
+}
+
+// Weights returns a copy of the log-odds weights.
+func (p *PWM) Weights() [][4]float64 {
+	return append([][4]float64(nil), p.weights...)
+}
+
+// Len returns the number of positions.
+func (p *PWM) Len() int {
+	return len(p.weights)
+}
+
+// max returns the best weight at position i
+func (p *PWM) max(i int) float64 {
+	w := p.weights[i]
+	return math.Max(math.Max(w[0], w[1]), math.Max(w[2], w[3]))
+}
+
+// MaxScore returns the score of the consensus.
+func (p *PWM) MaxScore() float64 {
+	return p.bound[0]
+}
+
+// Consensus returns the best scoring sequence.
+func (p *PWM) Consensus() []byte {
+	seq := make([]byte, p.Len())
+	for i, w := range p.weights {
+		best := 0
+		for b := range w {
+			if w[b] > w[best] {
+				best = b
+			}
+		}
+		seq[i] = "ACGT"[best]
+	}
+	return seq
+}
+
+// Score returns the score of window (Len bases); -Inf if it holds other bytes than ACGT (either case).
+func (p *PWM) Score(window []byte) float64 {
+	s := 0.
+	for i := range p.table {
+		s += p.table[i][window[i]]
+	}
+	return s
+}
+
+// score returns the score of window if it reaches threshold; branch and bound stops as soon as the
+// best possible score of the remaining positions can't reach it any more
+func (p *PWM) score(window []byte, threshold float64) (float64, bool) {
+	s := 0.
+	for k, i := range p.order {
+		if s+p.bound[k] < threshold {
+			return s, false
+		}
+		s += p.table[i][window[i]]
+	}
+	return s, s >= threshold
+}
+
+// ScanOptions tune PWM.Scan; the zero value scans all windows in parallel.
+type ScanOptions struct {
+	// Prefilter > 0 scores only windows holding the best scoring Prefilter bases of the consensus
+	// exactly, in either case (searched with bmatch). That is much faster on long genomes, but misses hits
+	// that deviate from the consensus within the core.
+	Prefilter int
+	// Workers scanning chunks in parallel; 0: runtime.NumCPU()
+	Workers int
+	// ChunkSize in bases; 0: 1<<20
+	ChunkSize int
+}
+
+// Scan returns the windows of genome scoring at least threshold in ascending order.
+func (p *PWM) Scan(genome []byte, threshold float64, opts *ScanOptions) ([]PWMHit, error) {
+	var o ScanOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Prefilter > p.Len() {
+		return nil, CORETOOLONG
+	}
+	if o.Workers < 1 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.ChunkSize < 1 {
+		o.ChunkSize = 1 << 20
+	}
+	var (
+		m       = p.Len()
+		windows = len(genome) - m + 1
+		core    []byte
+		coreOff int
+	)
+	if windows < 1 {
+		return nil, nil
+	}
+	if o.Prefilter > 0 {
+		core, coreOff = p.core(o.Prefilter)
+	}
+
+	// chunk k scores the windows starting in [k*ChunkSize, (k+1)*ChunkSize)
+	chunks := (windows + o.ChunkSize - 1) / o.ChunkSize
+	results := make([][]PWMHit, chunks)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < o.Workers && w < chunks; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range next {
+				start := k * o.ChunkSize
+				end := start + o.ChunkSize
+				if end > windows {
+					end = windows
+				}
+				if core != nil {
+					results[k] = p.scanCandidates(genome, start, end, core, coreOff, threshold)
+				} else {
+					results[k] = p.scanAll(genome, start, end, threshold)
+				}
+			}
+		}()
+	}
+	for k := 0; k < chunks; k++ {
+		next <- k
+	}
+	close(next)
+	wg.Wait()
+
+	var hits []PWMHit
+	for _, r := range results {
+		hits = append(hits, r...)
+	}
+	return hits, nil
+}
+
+// core returns the best scoring q bases of the consensus and their offset
+func (p *PWM) core(q int) ([]byte, int) {
+	var (
+		best    = math.Inf(-1)
+		bestOff int
+	)
+	for off := 0; off+q <= p.Len(); off++ {
+		s := 0.
+		for i := off; i < off+q; i++ {
+			s += p.max(i)
+		}
+		if s > best {
+			best, bestOff = s, off
+		}
+	}
+	return p.Consensus()[bestOff : bestOff+q], bestOff
+}
+
+func (p *PWM) scanAll(genome []byte, start, end int, threshold float64) (hits []PWMHit) {
+	m := p.Len()
+	for i := start; i < end; i++ {
+		if s, ok := p.score(genome[i:i+m], threshold); ok {
+			hits = append(hits, PWMHit{i, s})
+		}
+	}
+	return hits
+}
+
+func (p *PWM) scanCandidates(genome []byte, start, end int, core []byte, coreOff int, threshold float64) (hits []PWMHit) {
+	var (
+		m    = p.Len()
+		part = genome[start+coreOff : end+coreOff+len(core)-1]
+	)
+	if len(part) < len(core) {
+		return nil
+	}
+	// fold soft-masked (lower case) bases, Score takes either case
+	folded := make([]byte, len(part))
+	for i, c := range part {
+		folded[i] = upper[c]
+	}
+	found, _ := bmatch.FindAll(&folded, &core)
+	for _, c := range found {
+		i := start + c
+		if s, ok := p.score(genome[i:i+m], threshold); ok {
+			hits = append(hits, PWMHit{i, s})
+		}
+	}
+	return hits
+}
@@ -0,0 +1,188 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomPWM(r *rand.Rand, m int) *PWM {
+	counts := make([][4]float64, m)
+	for i := range counts {
+		for b := range counts[i] {
+			counts[i][b] = float64(r.Intn(20))
+		}
+		counts[i][r.Intn(4)] += 60
+	}
+	p, e := PWMFromCounts(counts, [4]float64{0.25, 0.25, 0.25, 0.25}, 0.5)
+	if e != nil {
+		panic(e)
+	}
+	return p
+}
+
+func referencePWM(p *PWM, genome []byte, threshold float64) (hits []PWMHit) {
+	for i := 0; i+p.Len() <= len(genome); i++ {
+		if s := p.Score(genome[i : i+p.Len()]); s >= threshold {
+			hits = append(hits, PWMHit{i, s})
+		}
+	}
+	return hits
+}
+
+func TestPWM_Scan(t *testing.T) {
+	r := rand.New(rand.NewSource(35))
+	genome := randomSeq(r, 100000)
+	for _, m := range []int{1, 6, 12, 20} {
+		p := randomPWM(r, m)
+		// plant the consensus and near consensus sites
+		for i := 0; i < 50; i++ {
+			off := r.Intn(len(genome) - m)
+			copy(genome[off:], p.Consensus())
+			genome[off+r.Intn(m)] = "ACGTn"[r.Intn(5)]
+		}
+		threshold := 0.6 * p.MaxScore()
+		want := referencePWM(p, genome, threshold)
+		for _, opts := range []*ScanOptions{nil, {Workers: 3, ChunkSize: 999}, {Workers: 1, ChunkSize: 1}} {
+			got, e := p.Scan(genome, threshold, opts)
+			if e != nil || len(got) != len(want) {
+				t.Fatalf("m=%d %+v: Scan = %d hits, %v; want %d", m, opts, len(got), e, len(want))
+			}
+			for i := range got {
+				if got[i].Offset != want[i].Offset || math.Abs(got[i].Score-want[i].Score) > 1e-9 {
+					t.Fatalf("m=%d %+v: hit %d = %v; want %v", m, opts, i, got[i], want[i])
+				}
+			}
+		}
+
+		// prefilter: the hits holding the consensus core exactly
+		q := m / 2
+		core, coreOff := p.core(q)
+		var wantCore []PWMHit
+		for _, h := range want {
+			if bytes.Equal(genome[h.Offset+coreOff:h.Offset+coreOff+q], core) {
+				wantCore = append(wantCore, h)
+			}
+		}
+		got, e := p.Scan(genome, threshold, &ScanOptions{Prefilter: q, ChunkSize: 777})
+		if e != nil || len(got) != len(wantCore) {
+			t.Fatalf("m=%d prefilter: Scan = %d hits, %v; want %d", m, len(got), e, len(wantCore))
+		}
+		for i := range got {
+			if got[i].Offset != wantCore[i].Offset {
+				t.Fatalf("m=%d prefilter: hit %d = %v; want %v", m, i, got[i], wantCore[i])
+			}
+		}
+	}
+}
+
+func TestPWM_SoftMasked(t *testing.T) {
+	r := rand.New(rand.NewSource(135))
+	genome := randomSeq(r, 20000)
+	p := randomPWM(r, 10)
+	for i := 0; i < 30; i++ {
+		copy(genome[r.Intn(len(genome)-10):], p.Consensus())
+	}
+	threshold := 0.8 * p.MaxScore()
+	want, _ := p.Scan(genome, threshold, &ScanOptions{Prefilter: 4})
+	if len(want) < 30 {
+		t.Fatalf("upper case prefilter: %d hits; want >= 30", len(want))
+	}
+	// soft-masked: all lower case and mixed case windows
+	lower := bytes.ToLower(genome)
+	mixed := append([]byte(nil), genome...)
+	for i := range mixed {
+		if r.Intn(2) == 0 {
+			mixed[i] |= 0x20
+		}
+	}
+	for _, g := range [][]byte{lower, mixed} {
+		got, e := p.Scan(g, threshold, &ScanOptions{Prefilter: 4, ChunkSize: 999})
+		if e != nil || len(got) != len(want) {
+			t.Fatalf("soft-masked prefilter: Scan = %d hits, %v; want %d", len(got), e, len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("soft-masked prefilter: hit %d = %v; want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestPWM_Weights(t *testing.T) {
+	weights := [][4]float64{{1, 0, 0, 0}, {0, 2, 0, 0}}
+	p, _ := NewPWM(weights)
+	weights[0][0] = -5
+	w := p.Weights()
+	w[1][1] = -5
+	if p.MaxScore() != 3 || p.Weights()[0][0] != 1 || p.Weights()[1][1] != 2 {
+		t.Fatalf("PWM changed with the weights: MaxScore %v, Weights %v", p.MaxScore(), p.Weights())
+	}
+}
+
+func TestPWM_Errors(t *testing.T) {
+	if _, e := NewPWM(nil); e != EMPTYPWM {
+		t.Fatalf("NewPWM(nil): %v", e)
+	}
+	if _, e := NewPWM([][4]float64{{math.NaN(), 0, 0, 0}}); e != BADWEIGHT {
+		t.Fatalf("NewPWM(NaN): %v", e)
+	}
+	if _, e := PWMFromCounts([][4]float64{{1, 1, 1, 1}}, [4]float64{0, 1, 1, 1}, 1); e != BADCOUNTS {
+		t.Fatalf("PWMFromCounts: %v", e)
+	}
+	p, _ := NewPWM([][4]float64{{1, 0, 0, 0}, {0, 0, 0, 2}})
+	if _, e := p.Scan([]byte("AT"), 0, &ScanOptions{Prefilter: 3}); e != CORETOOLONG {
+		t.Fatalf("Scan: %v", e)
+	}
+	if s := p.Score([]byte("AN")); !math.IsInf(s, -1) {
+		t.Fatalf("Score(AN) = %v", s)
+	}
+	if string(p.Consensus()) != "AT" || p.MaxScore() != 3 {
+		t.Fatalf("Consensus %s, MaxScore %v", p.Consensus(), p.MaxScore())
+	}
+}
+
+func BenchmarkPWM_Scan(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	genome := randomSeq(r, 1<<22)
+	p := randomPWM(r, 15)
+	threshold := 0.8 * p.MaxScore()
+	for _, bc := range []struct {
+		name string
+		opts *ScanOptions
+	}{
+		{"Workers1", &ScanOptions{Workers: 1}},
+		{"Parallel", nil},
+		{"Prefilter6", &ScanOptions{Prefilter: 6}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(genome)))
+			for i := 0; i < b.N; i++ {
+				p.Scan(genome, threshold, bc.opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/AndreasBriese/bmatch"
+)
+
+// RecordHit is an occurrence of a needle in a FASTA or FASTQ record.
+type RecordHit struct {
+	ID     string // record header up to the first white space
+	Record int    // number of the record in the input, from 0
+	Offset int    // offset in the record's sequence, line breaks not counted
+}
+
+// sequence bytes searched at once (plus the m-1 carried over from the last window)
+const windowSize = 1 << 16
+
+// SearchRecords streams FASTA or FASTQ records from r and calls fn for each occurrence of needle
+// in the sequence of a record, in input order. The search stops when fn returns false.
+//
+// Header (>, @), comment (;) and FASTQ quality lines are skipped, and an occurrence may span any
+// number of line breaks. Letters compare case insensitive (soft masked bases match).
+// Records are never held in memory as a whole: their sequence is searched in windows
+// overlapping by len(needle)-1 bytes.
+func SearchRecords(r io.Reader, needle []byte, fn func(RecordHit) bool) error {
+	if len(needle) < 1 {
+		return NEEDLESHORT
+	}
+	s := &recordSearcher{
+		needle: bytes.ToUpper(needle),
+		fn:     fn,
+		record: -1,
+		buf:    make([]byte, 0, windowSize+len(needle)),
+	}
+	return s.run(bufio.NewReaderSize(r, 1<<16))
+}
+
+// FindAllRecords returns all occurrences of needle in the FASTA or FASTQ records read from r.
+func FindAllRecords(r io.Reader, needle []byte) (hits []RecordHit, e error) {
+	e = SearchRecords(r, needle, func(h RecordHit) bool {
+		hits = append(hits, h)
+		return true
+	})
+	return hits, e
+}
+
+// FindAllRecordsFile returns all occurrences of needle in the FASTA or FASTQ file path.
+func FindAllRecordsFile(path string, needle []byte) ([]RecordHit, error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+	return FindAllRecords(f, needle)
+}
+
+const (
+	inSequence = iota
+	inHeader
+	inQuality
+	inPlus // FASTQ '+' line
+)
+
+type recordSearcher struct {
+	needle   []byte
+	fn       func(RecordHit) bool
+	id       string
+	record   int
+	fastq    bool
+	seqLen   int    // bases of the record so far
+	qualLen  int    // quality bytes of the FASTQ record so far
+	buf      []byte // window of the sequence
+	bufStart int    // offset of buf[0] in the record
+	next     int    // offsets below next are reported already
+	stopped  bool
+}
+
+func (s *recordSearcher) run(rd *bufio.Reader) error {
+	var (
+		state     = inSequence
+		lineStart = true
+		header    []byte
+	)
+	for !s.stopped {
+		line, e := rd.ReadSlice('\n')
+		if len(line) > 0 {
+			if lineStart {
+				switch {
+				case (line[0] == '>' || line[0] == '@') && (state != inQuality || s.qualLen >= s.seqLen):
+					s.endRecord()
+					s.fastq = line[0] == '@'
+					state, header = inHeader, header[:0]
+					line = line[1:]
+				case line[0] == '+' && s.fastq && state == inSequence:
+					state = inPlus
+				case line[0] == ';' && !s.fastq && state == inSequence:
+					state = inPlus // comment, skipped like a '+' line
+				}
+			}
+			switch state {
+			case inHeader:
+				header = append(header, line...)
+			case inSequence:
+				s.addSequence(line)
+			case inQuality:
+				s.qualLen += len(bytes.TrimRight(line, "\r\n"))
+			}
+			lineStart = line[len(line)-1] == '\n'
+			if lineStart {
+				switch state {
+				case inHeader:
+					s.startRecord(header)
+					state = inSequence
+				case inPlus:
+					if s.fastq {
+						state = inQuality
+					} else {
+						state = inSequence
+					}
+				}
+			}
+		}
+		if e == bufio.ErrBufferFull {
+			continue
+		}
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return e
+		}
+	}
+	if state == inHeader {
+		s.startRecord(header)
+	}
+	s.endRecord()
+	return nil
+}
+
+func (s *recordSearcher) startRecord(header []byte) {
+	if f := bytes.Fields(header); len(f) > 0 {
+		s.id = string(f[0])
+	} else {
+		s.id = ""
+	}
+	s.record++
+	s.seqLen, s.qualLen, s.bufStart, s.next = 0, 0, 0, 0
+	s.buf = s.buf[:0]
+}
+
+// addSequence appends the bases of a (part of a) sequence line to the window
+func (s *recordSearcher) addSequence(line []byte) {
+	if s.record < 0 || s.stopped {
+		return
+	}
+	for _, c := range line {
+		switch c {
+		case '\n', '\r', ' ', '\t':
+			continue
+		}
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		s.buf = append(s.buf, c)
+		s.seqLen++
+		if len(s.buf) == cap(s.buf) {
+			s.flush()
+		}
+	}
+}
+
+// flush searches the window and keeps its last m-1 bytes
+func (s *recordSearcher) flush() {
+	m := len(s.needle)
+	if s.stopped || len(s.buf) < m {
+		return
+	}
+	found, _ := bmatch.FindAll(&s.buf, &s.needle)
+	for _, idx := range found {
+		if off := s.bufStart + idx; off >= s.next {
+			s.next = off + 1
+			if !s.fn(RecordHit{s.id, s.record, off}) {
+				s.stopped = true
+				return
+			}
+		}
+	}
+	keep := len(s.buf) - (m - 1)
+	s.bufStart += keep
+	s.buf = s.buf[:copy(s.buf, s.buf[keep:])]
+}
+
+func (s *recordSearcher) endRecord() {
+	s.flush()
+	s.buf = s.buf[:0]
+}
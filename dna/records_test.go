@@ -0,0 +1,143 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testRecord struct {
+	id  string
+	seq []byte
+}
+
+func writeFASTA(records []testRecord, width int) []byte {
+	var b bytes.Buffer
+	for _, r := range records {
+		fmt.Fprintf(&b, ">%s some description\n", r.id)
+		for i := 0; i < len(r.seq); i += width {
+			end := i + width
+			if end > len(r.seq) {
+				end = len(r.seq)
+			}
+			b.Write(bytes.ToLower(r.seq[i:end]))
+			b.WriteString("\r\n")
+		}
+	}
+	return b.Bytes()
+}
+
+func writeFASTQ(records []testRecord, width int) []byte {
+	var b bytes.Buffer
+	for _, r := range records {
+		fmt.Fprintf(&b, "@%s\n", r.id)
+		for i := 0; i < len(r.seq); i += width {
+			end := i + width
+			if end > len(r.seq) {
+				end = len(r.seq)
+			}
+			b.Write(r.seq[i:end])
+			b.WriteByte('\n')
+		}
+		b.WriteString("+\n")
+		// quality lines starting with '@' and '>' and holding the needle
+		qual := bytes.Repeat([]byte("@>"), len(r.seq))[:len(r.seq)]
+		copy(qual[len(qual)/2:], r.seq)
+		for i := 0; i < len(qual); i += width {
+			end := i + width
+			if end > len(qual) {
+				end = len(qual)
+			}
+			b.Write(qual[i:end])
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+func TestFindAllRecords(t *testing.T) {
+	r := rand.New(rand.NewSource(33))
+	var records []testRecord
+	for i, n := range []int{0, 5, 61, 1000, 3 * windowSize, 200000} {
+		records = append(records, testRecord{fmt.Sprintf("chr%d", i), randomSeq(r, n)})
+	}
+	for _, m := range []int{1, 2, 7, 30, 60, 61, 200} {
+		needle := bytes.ToLower(records[4].seq[windowSize-m/2 : windowSize-m/2+m])
+		var want []RecordHit
+		for i, rec := range records {
+			for _, off := range referenceAll(rec.seq, bytes.ToUpper(needle)) {
+				want = append(want, RecordHit{rec.id, i, off})
+			}
+		}
+		for _, width := range []int{60, 80, 1 << 20} {
+			for name, input := range map[string][]byte{
+				"fasta": writeFASTA(records, width),
+				"fastq": writeFASTQ(records, width),
+			} {
+				got, e := FindAllRecords(bytes.NewReader(input), needle)
+				if e != nil || len(got) != len(want) {
+					t.Fatalf("%s width %d m=%d: %d hits, %v; want %d", name, width, m, len(got), e, len(want))
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Fatalf("%s width %d m=%d: hit %d = %v; want %v", name, width, m, i, got[i], want[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSearchRecords_Stop(t *testing.T) {
+	input := []byte(">a\nACGTACGT\nACGT\n>b\nACGT\n")
+	var hits []RecordHit
+	e := SearchRecords(bytes.NewReader(input), []byte("ACG"), func(h RecordHit) bool {
+		hits = append(hits, h)
+		return len(hits) < 2
+	})
+	if e != nil || len(hits) != 2 || hits[1] != (RecordHit{"a", 0, 4}) {
+		t.Fatalf("SearchRecords = %v, %v", hits, e)
+	}
+}
+
+func TestFindAllRecordsFile(t *testing.T) {
+	dir, e := ioutil.TempDir("", "bmatch-dna")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "seq.fa")
+	if e := ioutil.WriteFile(path, []byte(">x\nGATT\nACA\n"), 0644); e != nil {
+		t.Fatal(e)
+	}
+	hits, e := FindAllRecordsFile(path, []byte("TTAC"))
+	if e != nil || len(hits) != 1 || hits[0] != (RecordHit{"x", 0, 2}) {
+		t.Fatalf("FindAllRecordsFile = %v, %v", hits, e)
+	}
+}
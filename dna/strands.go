@@ -0,0 +1,149 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+// Strand tells on which strand of the genome a hit lies.
+type Strand int8
+
+const (
+	Forward Strand = iota // the needle itself
+	Reverse               // the reverse complement of the needle
+)
+
+func (s Strand) String() string {
+	if s == Reverse {
+		return "-"
+	}
+	return "+"
+}
+
+// Hit is an occurrence of a needle on one strand; Offset is counted on the forward strand.
+type Hit struct {
+	Offset int
+	Strand Strand
+}
+
+// complement of the bases (either case) and of N
+var complement [256]byte
+
+// upper case of the bases and N, 0 else
+var upper [256]byte
+
+func init() {
+	for _, p := range []string{"AT", "CG", "GC", "TA", "NN"} {
+		for _, c := range []byte{p[0], p[0] | 0x20} {
+			complement[c] = p[1] | c&0x20
+			upper[c] = p[0]
+		}
+	}
+}
+
+// ReverseComplement returns the reverse complement of seq (ACGTN, either case; case is kept).
+func ReverseComplement(seq []byte) ([]byte, error) {
+	rc := make([]byte, len(seq))
+	for i, c := range seq {
+		if complement[c] == 0 {
+			return nil, INVALIDBASE
+		}
+		rc[len(seq)-1-i] = complement[c]
+	}
+	return rc, nil
+}
+
+// FindAllBothStrands returns the occurrences of needle and of its reverse complement in genome,
+// sorted by offset. Bases compare case insensitive; N matches N only.
+// A palindromic needle (its own reverse complement) gives a Forward and a Reverse hit at each offset.
+//
+// Both strands are found in a single pass: BNDM runs over the union of the needle and its reverse
+// complement (a class of two bases per position) and the candidates are verified against either strand.
+func FindAllBothStrands(genome, needle []byte) (hits []Hit, e error) {
+	var (
+		n      = len(genome)
+		m      = len(needle)
+		p      = m // length of the prefix searched with BNDM
+		bitPat [256]uint64
+		fwd    = make([]byte, m)
+		rev    []byte
+	)
+	if m < 1 {
+		return nil, NEEDLESHORT
+	}
+	if rev, e = ReverseComplement(needle); e != nil {
+		return nil, e
+	}
+	for i := range needle {
+		fwd[i] = upper[needle[i]]
+		rev[i] = upper[rev[i]]
+	}
+	if m > n {
+		return nil, nil
+	}
+
+	// preprocessing
+	if p > 64 {
+		p = 64
+	}
+	for i := 0; i < p; i++ {
+		for _, c := range []byte{fwd[i], rev[i]} {
+			bitPat[c] |= 1 << uint(p-1-i)
+			bitPat[c|0x20] |= 1 << uint(p-1-i)
+		}
+	}
+
+	// search
+	high := uint64(1) << uint(p-1)
+	for pos := 0; pos <= n-m; {
+		j, last, bits := p, p, ^uint64(0)
+		for bits != 0 {
+			bits &= bitPat[genome[pos+j-1]]
+			j--
+			if bits&high != 0 {
+				if j > 0 {
+					last = j
+				} else {
+					// prefix of length p matches the union; verify both strands
+					if equalUpper(genome[pos:pos+m], fwd) {
+						hits = append(hits, Hit{pos, Forward})
+					}
+					if equalUpper(genome[pos:pos+m], rev) {
+						hits = append(hits, Hit{pos, Reverse})
+					}
+					break
+				}
+			}
+			bits <<= 1
+		}
+		pos += last
+	}
+	return hits, nil
+}
+
+// equalUpper compares seq case insensitive with the upper case bases in pat
+func equalUpper(seq, pat []byte) bool {
+	for i, c := range pat {
+		if upper[seq[i]] != c {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+// go package dna
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dna
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestReverseComplement(t *testing.T) {
+	rc, e := ReverseComplement([]byte("AACGtn"))
+	if e != nil || string(rc) != "naCGTT" {
+		t.Fatalf("ReverseComplement = %s, %v", rc, e)
+	}
+	if _, e := ReverseComplement([]byte("ACX")); e != INVALIDBASE {
+		t.Fatalf("ReverseComplement invalid: %v", e)
+	}
+}
+
+func TestFindAllBothStrands(t *testing.T) {
+	r := rand.New(rand.NewSource(32))
+	genome := randomSeq(r, 50000)
+	for i := 0; i < 500; i++ {
+		genome[r.Intn(len(genome))] = "acgtN"[r.Intn(5)]
+	}
+	upperGenome := bytes.ToUpper(genome)
+	for m := 1; m <= 100; m++ {
+		for _, needle := range [][]byte{genome[m*7 : m*8], randomSeq(r, m), []byte("ACGTTACGTA")[:m%10+1]} {
+			rc, _ := ReverseComplement(needle)
+			want := []Hit{}
+			fwdHits := referenceAll(upperGenome, bytes.ToUpper(needle))
+			revHits := referenceAll(upperGenome, bytes.ToUpper(rc))
+			for len(fwdHits)+len(revHits) > 0 {
+				if len(revHits) == 0 || len(fwdHits) > 0 && fwdHits[0] <= revHits[0] {
+					want, fwdHits = append(want, Hit{fwdHits[0], Forward}), fwdHits[1:]
+				} else {
+					want, revHits = append(want, Hit{revHits[0], Reverse}), revHits[1:]
+				}
+			}
+			got, e := FindAllBothStrands(genome, needle)
+			if e != nil || len(got) != len(want) {
+				t.Fatalf("FindAllBothStrands(%s) = %v, %v; want %v", needle, got, e, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("FindAllBothStrands(%s) = %v; want %v", needle, got, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkFindAllBothStrands(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	genome := randomSeq(r, 1<<22)
+	needle := genome[1<<21 : 1<<21+20]
+	b.SetBytes(int64(len(genome)))
+	for i := 0; i < b.N; i++ {
+		FindAllBothStrands(genome, needle)
+	}
+}
@@ -0,0 +1,70 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// FuzzMemchr compares mmIndex, mmCount and mmFindALL with bytes.Index;
+// skip shifts the haystack against the word alignment:
+// go test -fuzz FuzzMemchr
+func FuzzMemchr(f *testing.F) {
+	for _, s := range testcorpus.Seeds() {
+		for skip := uint8(0); skip < 8; skip += 3 {
+			f.Add(s.Haystack, s.Needle[len(s.Needle)-1], skip)
+		}
+	}
+	f.Add([]byte{}, byte(0), uint8(0))
+	f.Add([]byte("x"), byte('x'), uint8(0))
+	f.Fuzz(func(t *testing.T, hay []byte, c byte, skip uint8) {
+		if int(skip) <= len(hay) {
+			hay = hay[skip:]
+		}
+		needle := []byte{c}
+		if len(hay) == 0 {
+			return
+		}
+		if e := testcorpus.Compare(memchr{}, hay, needle); e != nil {
+			t.Fatalf("haystack %q, needle %q: %v", hay, needle, e)
+		}
+	})
+}
+
+// FuzzDispatch compares the dispatching Index, Count and FindAll with bytes.Index.
+func FuzzDispatch(f *testing.F) {
+	for _, s := range testcorpus.Seeds() {
+		f.Add(s.Haystack, s.Needle)
+	}
+	m, _ := NewMatcher(DefaultProfile())
+	f.Fuzz(func(t *testing.T, hay, needle []byte) {
+		if len(needle) < 1 || len(needle) > len(hay) {
+			return
+		}
+		if e := testcorpus.Compare(m, hay, needle); e != nil {
+			t.Fatalf("haystack %q, needle %q: %v", hay, needle, e)
+		}
+	})
+}
@@ -0,0 +1,3 @@
+module github.com/AndreasBriese/bmatch
+
+go 1.18
@@ -0,0 +1,434 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * On-disk format of the indexes (all numbers little endian):
+ *
+ *   header, 80 bytes:
+ *     0  magic "BMATCHIX"
+ *     8  format version (uint32)
+ *    12  kind: 1 SuffixArray, 2 FMIndex, 3 QGramIndex (uint32)
+ *    16  corpus length (uint64)
+ *    24  SHA-256 of the corpus (QGramIndex: of each document's length and bytes)
+ *    56  payload length (uint64)
+ *    64  CRC-32C of the payload (uint32)
+ *    68  CRC-32C of header bytes 0..67 (uint32)
+ *    72  reserved
+ *   payload: the fields of the index; every array is its length (uint64)
+ *     followed by its elements, starting at a multiple of 8 bytes
+ *
+ * Load* map the file read-only (Linux, see mmap_linux.go) and use the arrays in place
+ * where the machine is little endian (see view_le.go); elsewhere the file is read and decoded.
+ * The payload checksum is verified on every load.
+ */
+
+package index
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// FormatVersion is the version of the on-disk format written by Save.
+const FormatVersion = 1
+
+const (
+	magic      = "BMATCHIX"
+	headerSize = 80
+
+	kindSuffixArray = 1
+	kindFMIndex     = 2
+	kindQGramIndex  = 3
+)
+
+// Errors
+var (
+	BADMAGIC       = errors.New("not a bmatch index file")
+	BADVERSION     = errors.New("unsupported index format version")
+	BADKIND        = errors.New("index file holds another kind of index")
+	CORRUPT        = errors.New("index file is corrupt")
+	CORPUSMISMATCH = errors.New("index was built over another corpus")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type header struct {
+	kind       uint32
+	corpusLen  uint64
+	corpusHash [32]byte
+	payloadLen uint64
+	payloadCRC uint32
+}
+
+func (h *header) encode() []byte {
+	b := make([]byte, headerSize)
+	copy(b, magic)
+	binary.LittleEndian.PutUint32(b[8:], FormatVersion)
+	binary.LittleEndian.PutUint32(b[12:], h.kind)
+	binary.LittleEndian.PutUint64(b[16:], h.corpusLen)
+	copy(b[24:56], h.corpusHash[:])
+	binary.LittleEndian.PutUint64(b[56:], h.payloadLen)
+	binary.LittleEndian.PutUint32(b[64:], h.payloadCRC)
+	binary.LittleEndian.PutUint32(b[68:], crc32.Checksum(b[:68], castagnoli))
+	return b
+}
+
+// decodeHeader checks the header and the payload of the file b of the expected kind
+func decodeHeader(b []byte, kind uint32) (h header, payload []byte, e error) {
+	switch {
+	case len(b) < headerSize || string(b[:8]) != magic:
+		return h, nil, BADMAGIC
+	case binary.LittleEndian.Uint32(b[68:]) != crc32.Checksum(b[:68], castagnoli):
+		return h, nil, CORRUPT
+	case binary.LittleEndian.Uint32(b[8:]) != FormatVersion:
+		return h, nil, BADVERSION
+	}
+	h.kind = binary.LittleEndian.Uint32(b[12:])
+	h.corpusLen = binary.LittleEndian.Uint64(b[16:])
+	copy(h.corpusHash[:], b[24:56])
+	h.payloadLen = binary.LittleEndian.Uint64(b[56:])
+	h.payloadCRC = binary.LittleEndian.Uint32(b[64:])
+	if h.kind != kind {
+		return h, nil, BADKIND
+	}
+	if h.payloadLen != uint64(len(b)-headerSize) {
+		return h, nil, CORRUPT
+	}
+	payload = b[headerSize:]
+	if crc32.Checksum(payload, castagnoli) != h.payloadCRC {
+		return h, nil, CORRUPT
+	}
+	return h, payload, nil
+}
+
+// corpusHash returns the SHA-256 of the documents, of data alone for a single one
+func corpusHash(docs ...[]byte) (sum [32]byte, n uint64) {
+	if len(docs) == 1 {
+		return sha256.Sum256(docs[0]), uint64(len(docs[0]))
+	}
+	h := sha256.New()
+	var l [8]byte
+	for _, d := range docs {
+		binary.LittleEndian.PutUint64(l[:], uint64(len(d)))
+		h.Write(l[:])
+		h.Write(d)
+		n += uint64(len(d))
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, n
+}
+
+// checkCorpus compares the header with the corpus
+func (h *header) checkCorpus(sum [32]byte, n uint64) error {
+	if h.corpusLen != n || h.corpusHash != sum {
+		return CORPUSMISMATCH
+	}
+	return nil
+}
+
+// encoder writes the payload, keeping its length and checksum
+type encoder struct {
+	w   *bufio.Writer
+	crc uint32
+	off uint64
+	e   error
+	buf [8]byte
+}
+
+func (en *encoder) write(b []byte) {
+	if en.e != nil {
+		return
+	}
+	en.crc = crc32.Update(en.crc, castagnoli, b)
+	en.off += uint64(len(b))
+	_, en.e = en.w.Write(b)
+}
+
+func (en *encoder) u64(v uint64) {
+	binary.LittleEndian.PutUint64(en.buf[:], v)
+	en.write(en.buf[:])
+}
+
+func (en *encoder) pad() {
+	var zero [8]byte
+	en.write(zero[:(8-en.off%8)%8])
+}
+
+func (en *encoder) int32s(v []int32) {
+	en.u64(uint64(len(v)))
+	for _, x := range v {
+		binary.LittleEndian.PutUint32(en.buf[:4], uint32(x))
+		en.write(en.buf[:4])
+	}
+	en.pad()
+}
+
+func (en *encoder) uint32s(v []uint32) {
+	en.u64(uint64(len(v)))
+	for _, x := range v {
+		binary.LittleEndian.PutUint32(en.buf[:4], x)
+		en.write(en.buf[:4])
+	}
+	en.pad()
+}
+
+func (en *encoder) postings(v []posting) {
+	en.u64(uint64(len(v)))
+	for _, p := range v {
+		binary.LittleEndian.PutUint32(en.buf[:4], uint32(p.doc))
+		binary.LittleEndian.PutUint32(en.buf[4:], uint32(p.offset))
+		en.write(en.buf[:])
+	}
+}
+
+func (en *encoder) uint64s(v []uint64) {
+	en.u64(uint64(len(v)))
+	for _, x := range v {
+		en.u64(x)
+	}
+}
+
+func (en *encoder) ints(v []int) {
+	en.u64(uint64(len(v)))
+	for _, x := range v {
+		en.u64(uint64(x))
+	}
+}
+
+func (en *encoder) bitVector(b *bitVector) {
+	en.u64(uint64(b.n))
+	en.uint64s(b.words)
+	en.uint32s(b.super)
+}
+
+// save writes the header and the payload written by fields to path
+func save(path string, h header, fields func(*encoder)) (e error) {
+	// written to a temporary file renamed over path at the end: an index loaded from path
+	// keeps reading the old file, and a crash leaves no half written index behind
+	f, e := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if e != nil {
+		return e
+	}
+	defer func() {
+		if e != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	// the payload is written behind a blank header, which is filled in at the end
+	if _, e = f.Write(make([]byte, headerSize)); e != nil {
+		return e
+	}
+	en := &encoder{w: bufio.NewWriterSize(f, 1<<20)}
+	fields(en)
+	if en.e != nil {
+		return en.e
+	}
+	if e = en.w.Flush(); e != nil {
+		return e
+	}
+	h.payloadLen, h.payloadCRC = en.off, en.crc
+	if _, e = f.WriteAt(h.encode(), 0); e != nil {
+		return e
+	}
+	if e = f.Chmod(0644); e != nil {
+		return e
+	}
+	if e = f.Sync(); e != nil {
+		return e
+	}
+	if e = f.Close(); e != nil {
+		return e
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// decoder reads the payload; a short payload sets CORRUPT and yields zero values
+type decoder struct {
+	b   []byte
+	off int
+	e   error
+}
+
+func (de *decoder) take(n uint64) []byte {
+	if de.e != nil || n > uint64(len(de.b)-de.off) {
+		de.e = CORRUPT
+		return nil
+	}
+	b := de.b[de.off : de.off+int(n)]
+	de.off += int(n)
+	return b
+}
+
+func (de *decoder) u64() uint64 {
+	if b := de.take(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (de *decoder) int() int {
+	v := de.u64()
+	if v > math.MaxInt32 {
+		de.e = CORRUPT
+		return 0
+	}
+	return int(v)
+}
+
+func (de *decoder) pad() {
+	de.take(uint64((8 - de.off%8) % 8))
+}
+
+// elems returns the bytes of an array of elements of size bytes
+func (de *decoder) elems(size uint64) []byte {
+	l := de.u64()
+	if l > uint64(len(de.b))/size {
+		de.e = CORRUPT
+		return nil
+	}
+	b := de.take(l * size)
+	de.pad()
+	return b
+}
+
+func decodeInt32s(b []byte) []int32 {
+	v := make([]int32, len(b)/4)
+	for i := range v {
+		v[i] = int32(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+func decodeUint32s(b []byte) []uint32 {
+	v := make([]uint32, len(b)/4)
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return v
+}
+
+func decodeUint64s(b []byte) []uint64 {
+	v := make([]uint64, len(b)/8)
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	return v
+}
+
+func decodePostings(b []byte) []posting {
+	v := make([]posting, len(b)/8)
+	for i := range v {
+		v[i] = posting{int32(binary.LittleEndian.Uint32(b[8*i:])), int32(binary.LittleEndian.Uint32(b[8*i+4:]))}
+	}
+	return v
+}
+
+func (de *decoder) int32s() []int32 {
+	return viewInt32s(de.elems(4))
+}
+
+func (de *decoder) uint32s() []uint32 {
+	return viewUint32s(de.elems(4))
+}
+
+func (de *decoder) uint64s() []uint64 {
+	return viewUint64s(de.elems(8))
+}
+
+func (de *decoder) postings() []posting {
+	return viewPostings(de.elems(8))
+}
+
+func (de *decoder) ints() []int {
+	b := de.elems(8)
+	v := make([]int, len(b)/8)
+	for i := range v {
+		v[i] = int(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return v
+}
+
+func (de *decoder) bitVector() *bitVector {
+	b := &bitVector{n: de.int()}
+	b.words = de.uint64s()
+	b.super = de.uint32s()
+	if de.e == nil && (len(b.words) != (b.n+63)>>6 || len(b.super) != len(b.words)>>3+1) {
+		de.e = CORRUPT
+	}
+	return b
+}
+
+// load maps or reads the file at path and checks its header and payload
+func load(path string, kind uint32) (h header, de *decoder, m *mapping, e error) {
+	if m, e = mapFile(path); e != nil {
+		return h, nil, nil, e
+	}
+	h, payload, e := decodeHeader(m.data, kind)
+	if e != nil {
+		m.close()
+		return h, nil, nil, e
+	}
+	return h, &decoder{b: payload}, m, nil
+}
+
+// finish closes the mapping on errors of de
+func finish(de *decoder, m *mapping) error {
+	if de.e != nil {
+		m.close()
+		return de.e
+	}
+	return nil
+}
+
+// mapping is an index file mapped (or read) into memory
+type mapping struct {
+	data  []byte
+	unmap func([]byte) error
+}
+
+// close unmaps the file; the index must not be used afterwards
+func (m *mapping) close() error {
+	if m == nil || m.unmap == nil {
+		return nil
+	}
+	e := m.unmap(m.data)
+	m.data, m.unmap = nil, nil
+	return e
+}
+
+// readAll is the fallback of mapFile
+func readAll(f *os.File) (*mapping, error) {
+	data, e := io.ReadAll(f)
+	if e != nil {
+		return nil, e
+	}
+	return &mapping{data: data}, nil
+}
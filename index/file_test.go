@@ -0,0 +1,198 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package index
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// searcher is the query interface shared by the indexes over a single haystack
+type searcher interface {
+	Count(needle *[]byte) (int, error)
+	FindAll(needle *[]byte) ([]int, error)
+}
+
+// sameResults compares the answers of a and b to needles of the corpus
+func sameResults(t *testing.T, data []byte, a, b searcher) {
+	t.Helper()
+	for _, m := range []int{1, 3, 12} {
+		for _, needle := range testcorpus.Needles(int64(m), data, m, 10) {
+			ca, _ := a.Count(&needle)
+			cb, _ := b.Count(&needle)
+			fa, _ := a.FindAll(&needle)
+			fb, _ := b.FindAll(&needle)
+			if ca != cb || len(fa) != len(fb) {
+				t.Fatalf("needle %q: count %d, %d; found %d, %d", needle, ca, cb, len(fa), len(fb))
+			}
+			for k := range fa {
+				if fa[k] != fb[k] {
+					t.Fatalf("needle %q: found %v, %v", needle, fa, fb)
+				}
+			}
+		}
+	}
+}
+
+func TestFile_SuffixArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "text.sa")
+	data := testcorpus.Text(1, 1<<16)
+	s, _ := NewSuffixArray(data)
+	if e := s.Save(path); e != nil {
+		t.Fatal(e)
+	}
+	l, e := LoadSuffixArray(path, data)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer l.Close()
+	sameResults(t, data, s, l)
+	if _, e := LoadSuffixArray(path, data[1:]); e != CORPUSMISMATCH {
+		t.Errorf("other corpus: %v; want CORPUSMISMATCH", e)
+	}
+	if _, e := LoadFMIndex(path, nil); e != BADKIND {
+		t.Errorf("load as FM-index: %v; want BADKIND", e)
+	}
+}
+
+func TestFile_FMIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dna.fm")
+	data := testcorpus.DNA(1, 1<<16)
+	f, _ := NewFMIndex(data, 8)
+	if e := f.Save(path); e != nil {
+		t.Fatal(e)
+	}
+	for _, corpus := range [][]byte{data, nil} {
+		l, e := LoadFMIndex(path, corpus)
+		if e != nil {
+			t.Fatal(e)
+		}
+		sameResults(t, data, f, l)
+		l.Close()
+	}
+	if _, e := LoadFMIndex(path, testcorpus.DNA(2, 1<<16)); e != CORPUSMISMATCH {
+		t.Errorf("other corpus: %v; want CORPUSMISMATCH", e)
+	}
+}
+
+func TestFile_QGramIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.qg")
+	data := testcorpus.Text(3, 1<<15)
+	var docs [][]byte
+	for i := 0; i < len(data); i += 1000 {
+		docs = append(docs, data[i:min(i+1000, len(data))])
+	}
+	x, _ := NewQGramIndex(4)
+	for _, d := range docs[:len(docs)-1] {
+		x.Add(d)
+	}
+	if e := x.Save(path); e != nil {
+		t.Fatal(e)
+	}
+	l, e := LoadQGramIndex(path, docs[:len(docs)-1])
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer l.Close()
+	if l.Q() != 4 || l.Len() != len(docs)-1 {
+		t.Fatalf("loaded q %d, %d documents", l.Q(), l.Len())
+	}
+	// documents added after loading go to copies of the mapped postings
+	x.Add(docs[len(docs)-1])
+	l.Add(docs[len(docs)-1])
+	for _, needle := range testcorpus.Needles(7, data, 7, 20) {
+		a, _ := x.FindAll(&needle)
+		b, _ := l.FindAll(&needle)
+		if len(a) != len(b) {
+			t.Fatalf("needle %q: %v, %v", needle, a, b)
+		}
+		for k := range a {
+			if a[k] != b[k] {
+				t.Fatalf("needle %q: %v, %v", needle, a, b)
+			}
+		}
+	}
+	if _, e := LoadQGramIndex(path, docs); e != CORPUSMISMATCH {
+		t.Errorf("other documents: %v; want CORPUSMISMATCH", e)
+	}
+
+	// saving over the file replaces it; the loaded index keeps its mapping
+	if e := l.Save(path); e != nil {
+		t.Fatal(e)
+	}
+	r, e := LoadQGramIndex(path, docs)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer r.Close()
+	for _, needle := range testcorpus.Needles(5, data, 5, 20) {
+		a, _ := l.Count(&needle)
+		b, _ := r.Count(&needle)
+		c, _ := x.Count(&needle)
+		if a != c || b != c {
+			t.Fatalf("needle %q: counts %d, %d; want %d", needle, a, b, c)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*")); len(files) != 0 {
+		t.Errorf("temporary files left: %v", files)
+	}
+}
+
+func TestFile_Damaged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "text.sa")
+	data := testcorpus.Text(1, 1<<12)
+	s, _ := NewSuffixArray(data)
+	if e := s.Save(path); e != nil {
+		t.Fatal(e)
+	}
+	file, _ := os.ReadFile(path)
+
+	for _, c := range []struct {
+		name   string
+		change func(b []byte) []byte
+		want   error
+	}{
+		{"payload", func(b []byte) []byte { b[headerSize+100] ^= 1; return b }, CORRUPT},
+		{"header", func(b []byte) []byte { b[20] ^= 1; return b }, CORRUPT},
+		{"truncated", func(b []byte) []byte { return b[:len(b)-8] }, CORRUPT},
+		{"magic", func(b []byte) []byte { b[0] = 'X'; return b }, BADMAGIC},
+		{"empty", func(b []byte) []byte { return b[:0] }, BADMAGIC},
+		{"version", func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[8:], FormatVersion+1)
+			binary.LittleEndian.PutUint32(b[68:], crc32.Checksum(b[:68], castagnoli))
+			return b
+		}, BADVERSION},
+	} {
+		p := filepath.Join(dir, c.name)
+		os.WriteFile(p, c.change(append([]byte(nil), file...)), 0644)
+		if _, e := LoadSuffixArray(p, data); e != c.want {
+			t.Errorf("%s: %v; want %v", c.name, e, c.want)
+		}
+	}
+}
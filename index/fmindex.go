@@ -0,0 +1,286 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * 'nos esse quasi nanos gigantum umeris insidentes' (Bernhard von Chartres, 1120)
+ * The giants in this respect:
+ * The FM-index is published by P. Ferragina and G. Manzini (2000):
+ * Opportunistic Data Structures with Applications.
+ * Proceedings of the 41st IEEE Symposium on Foundations of Computer Science, pp.390--398.
+ *
+ * Rows are the sorted suffixes of haystack+$ with the sentinel $ smaller than all bytes:
+ * row 0 is "$", row r > 0 the suffix sa[r-1]. The BWT (the byte before each row's suffix)
+ * is held as dense codes in a wavelet matrix; the $ of row dollar is stored as code 0
+ * and taken out of the counts of code 0.
+ */
+
+package index
+
+import (
+	"crypto/sha256"
+	"math"
+	"sort"
+)
+
+// DefaultSampleRate is the suffix array sampling of NewFMIndex for a sampleRate < 1
+const DefaultSampleRate = 32
+
+// FMIndex is a compressed full-text index of a haystack: it does not keep the haystack
+// and takes about log2(alphabet size)*17/16 bits per haystack byte plus the sampled suffix array.
+// An FMIndex is safe for concurrent use.
+type FMIndex struct {
+	n       int
+	codes   [256]int16 // dense code of each byte, -1 if absent from the haystack
+	c       []int      // c[code]: rows before the first one starting with code
+	bwt     *waveletMatrix
+	dollar  int // row holding $ in the BWT
+	rate    int
+	sampled *bitVector // rows whose offset is a multiple of rate
+	samples []int32    // offsets of the sampled rows in row order
+	hash    [32]byte   // SHA-256 of the haystack, for Save
+	m       *mapping   // file of LoadFMIndex
+}
+
+// NewFMIndex builds the FM-index of haystack. Every sampleRate-th offset is kept:
+// Locate of a match takes up to sampleRate-1 steps, the samples take 4/sampleRate bytes per byte.
+// sampleRate < 1 picks DefaultSampleRate.
+func NewFMIndex(haystack []byte, sampleRate int) (*FMIndex, error) {
+	if len(haystack) >= math.MaxInt32 {
+		return nil, TOOLARGE
+	}
+	if sampleRate < 1 {
+		sampleRate = DefaultSampleRate
+	}
+
+	var (
+		n     = len(haystack)
+		sa    = make([]int32, n)
+		f     = &FMIndex{n: n, rate: sampleRate, hash: sha256.Sum256(haystack)}
+		count [256]int
+		sigma int
+	)
+	sais(haystack, sa, 256)
+
+	for _, b := range haystack {
+		count[b]++
+	}
+	f.c = make([]int, 0, 256)
+	rows := 1 // the $ row
+	for b := range count {
+		f.codes[b] = -1
+		if count[b] > 0 {
+			f.codes[b] = int16(sigma)
+			f.c = append(f.c, rows)
+			rows += count[b]
+			sigma++
+		}
+	}
+	if sigma == 0 {
+		sigma = 1
+	}
+
+	// BWT and samples: row 0 is the suffix at n, row r the suffix at sa[r-1]
+	bwt := make([]byte, n+1)
+	f.sampled = newBitVector(n + 1)
+	offset := func(r int) int {
+		if r == 0 {
+			return n
+		}
+		return int(sa[r-1])
+	}
+	for r := 0; r <= n; r++ {
+		p := offset(r)
+		if p == 0 {
+			f.dollar = r
+		} else {
+			bwt[r] = byte(f.codes[haystack[p-1]])
+		}
+		if p%sampleRate == 0 {
+			f.sampled.set(r)
+			f.samples = append(f.samples, int32(p))
+		}
+	}
+	f.sampled.build()
+	sa = nil // free before the wavelet matrix is built
+	f.bwt = newWaveletMatrix(bwt, sigma)
+	return f, nil
+}
+
+// Len returns the length of the haystack.
+func (f *FMIndex) Len() int {
+	return f.n
+}
+
+// Size returns the bytes held by the index.
+func (f *FMIndex) Size() int {
+	return f.bwt.size() + f.sampled.size() + 4*len(f.samples) + 8*len(f.c) + 2*len(f.codes)
+}
+
+// occ returns the number of code c in the BWT rows [0, r)
+func (f *FMIndex) occ(c uint, r int) int {
+	o := f.bwt.rank(c, r)
+	if c == 0 && f.dollar < r {
+		o--
+	}
+	return o
+}
+
+// lookup returns the rows [sp, ep) starting with needle by backward search
+func (f *FMIndex) lookup(needle []byte) (sp, ep int) {
+	sp, ep = 0, f.n+1
+	for i := len(needle) - 1; i >= 0 && sp < ep; i-- {
+		code := f.codes[needle[i]]
+		if code < 0 {
+			return 0, 0
+		}
+		c := uint(code)
+		sp = f.c[c] + f.occ(c, sp)
+		ep = f.c[c] + f.occ(c, ep)
+	}
+	return sp, ep
+}
+
+// locate returns the offset of the suffix of row r by walking LF to a sampled row
+func (f *FMIndex) locate(r int) int {
+	steps := 0
+	for !f.sampled.get(r) {
+		// LF: the row of the suffix one byte to the left; the $ row is sampled (offset 0)
+		c, o := f.bwt.accessRank(r)
+		if c == 0 && f.dollar < r {
+			o--
+		}
+		r = f.c[c] + o
+		steps++
+	}
+	return int(f.samples[f.sampled.rank1(r)]) + steps
+}
+
+// Count returns the number of (overlapping) occurrences of needle in the haystack in O(m).
+func (f *FMIndex) Count(needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	sp, ep := f.lookup(*needle)
+	return ep - sp, nil
+}
+
+// FindAll returns the indices of all (overlapping) occurrences of needle in ascending order.
+func (f *FMIndex) FindAll(needle *[]byte) (found []int, e error) {
+	if len(*needle) < 1 {
+		return found, NEEDLESHORT
+	}
+	sp, ep := f.lookup(*needle)
+	if sp == ep {
+		return found, nil
+	}
+	found = make([]int, 0, ep-sp)
+	for r := sp; r < ep; r++ {
+		found = append(found, f.locate(r))
+	}
+	sort.Ints(found)
+	return found, nil
+}
+
+// Index returns the first (left) index of needle in the haystack or -1 if not present.
+// All occurrences are located to find the first one.
+func (f *FMIndex) Index(needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	sp, ep := f.lookup(*needle)
+	first := -1
+	for r := sp; r < ep; r++ {
+		if p := f.locate(r); first < 0 || p < first {
+			first = p
+		}
+	}
+	return first, nil
+}
+
+// Save writes the index to path (see file.go).
+func (f *FMIndex) Save(path string) error {
+	return save(path, header{kind: kindFMIndex, corpusLen: uint64(f.n), corpusHash: f.hash}, func(en *encoder) {
+		en.u64(uint64(f.n))
+		en.u64(uint64(f.rate))
+		en.u64(uint64(f.dollar))
+		codes := make([]int, len(f.codes))
+		for b, c := range f.codes {
+			codes[b] = int(c)
+		}
+		en.ints(codes)
+		en.ints(f.c)
+		en.ints(f.bwt.zeros)
+		en.ints(f.bwt.starts)
+		for _, bv := range f.bwt.levels {
+			en.bitVector(bv)
+		}
+		en.bitVector(f.sampled)
+		en.int32s(f.samples)
+	})
+}
+
+// LoadFMIndex loads the index saved at path. If haystack is not nil it must be the
+// haystack the index was built of, else LoadFMIndex fails with CORPUSMISMATCH.
+// On Linux the file is mapped until Close.
+func LoadFMIndex(path string, haystack []byte) (*FMIndex, error) {
+	h, de, m, e := load(path, kindFMIndex)
+	if e != nil {
+		return nil, e
+	}
+	if haystack != nil {
+		if e = h.checkCorpus(corpusHash(haystack)); e != nil {
+			m.close()
+			return nil, e
+		}
+	}
+	f := &FMIndex{n: de.int(), rate: de.int(), dollar: de.int(), hash: h.corpusHash, m: m}
+	codes := de.ints()
+	f.c = de.ints()
+	f.bwt = &waveletMatrix{zeros: de.ints(), starts: de.ints()}
+	for range f.bwt.zeros {
+		f.bwt.levels = append(f.bwt.levels, de.bitVector())
+	}
+	f.sampled = de.bitVector()
+	f.samples = de.int32s()
+	if de.e == nil && (len(codes) != len(f.codes) || len(f.c) != len(f.bwt.starts) || f.rate < 1 ||
+		f.sampled.n != f.n+1 || len(f.samples) != f.sampled.rank1(f.n+1)) {
+		de.e = CORRUPT
+	}
+	if de.e == nil {
+		for b, c := range codes {
+			if c >= len(f.c) {
+				de.e = CORRUPT
+			}
+			f.codes[b] = int16(c)
+		}
+	}
+	if e = finish(de, m); e != nil {
+		return nil, e
+	}
+	return f, nil
+}
+
+// Close releases the file of LoadFMIndex; the FMIndex must not be used afterwards.
+// It is a no-op for indexes built by NewFMIndex.
+func (f *FMIndex) Close() error {
+	return f.m.close()
+}
@@ -0,0 +1,113 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch"
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// TestFMIndex_Corpora compares the queries with bmatch on the test corpora
+func TestFMIndex_Corpora(t *testing.T) {
+	for _, c := range testcorpus.All(2, 1<<15) {
+		for _, rate := range []int{1, 5, 0} {
+			f, e := NewFMIndex(c.Data, rate)
+			if e != nil {
+				t.Fatal(e)
+			}
+			for _, m := range []int{1, 2, 3, 8, 20, 100} {
+				for _, needle := range testcorpus.Needles(int64(m), c.Data, m, 10) {
+					want, _ := bmatch.FindAll(&c.Data, &needle)
+					found, _ := f.FindAll(&needle)
+					count, _ := f.Count(&needle)
+					idx, _ := f.Index(&needle)
+					wantIdx := -1
+					if len(want) > 0 {
+						wantIdx = want[0]
+					}
+					if count != len(want) || idx != wantIdx || len(found) != len(want) {
+						t.Fatalf("%s rate %d needle %q: count %d index %d found %d; want %d, %d", c.Name, rate, needle, count, idx, len(found), len(want), wantIdx)
+					}
+					for k := range found {
+						if found[k] != want[k] {
+							t.Fatalf("%s rate %d needle %q: found %v; want %v", c.Name, rate, needle, found, want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFMIndex_Small(t *testing.T) {
+	for _, text := range []string{"", "a", "aaaa", "banana", "mississippi", "\x00\x00\x01\x00", "abracadabra"} {
+		hay := []byte(text)
+		f, e := NewFMIndex(hay, 2)
+		if e != nil {
+			t.Fatal(e)
+		}
+		for i := 0; i < len(text); i++ {
+			for j := i + 1; j <= len(text); j++ {
+				needle := []byte(text[i:j])
+				if n, _ := f.Count(&needle); n != len(testcorpus.FindAll(hay, needle)) {
+					t.Fatalf("text %q needle %q: count %d", text, needle, n)
+				}
+				if found, _ := f.FindAll(&needle); len(found) == 0 || bytes.Index(hay, needle) != found[0] {
+					t.Fatalf("text %q needle %q: found %v", text, needle, found)
+				}
+			}
+		}
+		needle := []byte("z")
+		if n, _ := f.Count(&needle); n != 0 {
+			t.Fatalf("text %q: count of absent byte %d", text, n)
+		}
+	}
+}
+
+// TestFMIndex_Size checks the index of a DNA sequence holds less than a byte per base
+func TestFMIndex_Size(t *testing.T) {
+	genome := testcorpus.DNA(1, 1<<20)
+	f, _ := NewFMIndex(genome, 32)
+	if s := f.Size(); s >= len(genome) {
+		t.Errorf("FM-index of %d bases takes %d bytes", len(genome), s)
+	}
+}
+
+// BenchmarkFMIndex_Count counts 1000 needles of 8 to 32 bases in a genome of 4M bases
+func BenchmarkFMIndex_Count(b *testing.B) {
+	genome := testcorpus.DNA(1, 1<<22)
+	f, _ := NewFMIndex(genome, 0)
+	var needles [][]byte
+	for m := 8; m <= 32; m += 8 {
+		needles = append(needles, testcorpus.Needles(int64(m), genome, m, 250)...)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k := range needles {
+			f.Count(&needles[k])
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package index
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/AndreasBriese/bmatch"
+)
+
+// DefaultQ is the q-gram length of NewQGramIndex for q < 1 - the three bytes hashed by bhsearch
+const DefaultQ = 3
+
+// Errors
+var (
+	BADQ = errors.New("q-gram length is larger 8")
+)
+
+// DocHit is a match of a QGramIndex query: the document id returned by Add and the offset in it.
+type DocHit struct {
+	Doc    int
+	Offset int
+}
+
+// posting is an occurrence of a q-gram
+type posting struct {
+	doc, offset int32
+}
+
+// QGramIndex is an inverted index of the q-grams of a growing set of documents.
+// A query intersects the postings of the needle's q-grams and verifies the candidates with bmatch;
+// needles shorter than q are searched with bmatch in every document.
+// The documents must not be changed after Add. A QGramIndex is safe for concurrent use.
+type QGramIndex struct {
+	q        int
+	mu       sync.RWMutex
+	docs     [][]byte
+	postings map[uint64][]posting // ordered by doc and offset
+	m        *mapping             // file of LoadQGramIndex
+}
+
+// NewQGramIndex returns an empty index of q-grams of length q (1 to 8; q < 1 picks DefaultQ).
+func NewQGramIndex(q int) (*QGramIndex, error) {
+	if q < 1 {
+		q = DefaultQ
+	}
+	if q > 8 {
+		return nil, BADQ
+	}
+	return &QGramIndex{q: q, postings: map[uint64][]posting{}}, nil
+}
+
+// Q returns the q-gram length.
+func (x *QGramIndex) Q() int {
+	return x.q
+}
+
+// Len returns the number of documents.
+func (x *QGramIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// gram packs the q bytes at p into a key
+func gram(p []byte) (g uint64) {
+	for _, b := range p {
+		g = g<<8 | uint64(b)
+	}
+	return g
+}
+
+// Add indexes doc and returns its id, the number of documents added before.
+func (x *QGramIndex) Add(doc []byte) (int, error) {
+	if len(doc) > math.MaxInt32 {
+		return -1, TOOLARGE
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.docs) == math.MaxInt32 {
+		return -1, TOOLARGE
+	}
+	id := int32(len(x.docs))
+	x.docs = append(x.docs, doc)
+	for i := 0; i+x.q <= len(doc); i++ {
+		g := gram(doc[i : i+x.q])
+		x.postings[g] = append(x.postings[g], posting{id, int32(i)})
+	}
+	return int(id), nil
+}
+
+// search reports the matches of needle in ascending order to report, until it returns true
+func (x *QGramIndex) search(needle []byte, report func(DocHit) bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	m := len(needle)
+	if m < x.q {
+		for d := range x.docs {
+			found, _ := bmatch.FindAll(&x.docs[d], &needle)
+			for _, i := range found {
+				if report(DocHit{d, i}) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	// q-grams covering the needle: at 0, q, 2q, .. and m-q; the shortest postings list leads
+	type part struct {
+		shift int32
+		list  []posting
+	}
+	var parts []part
+	for k := 0; ; k += x.q {
+		if k > m-x.q {
+			k = m - x.q
+		}
+		list := x.postings[gram(needle[k:k+x.q])]
+		if len(list) == 0 {
+			return
+		}
+		parts = append(parts, part{int32(k), list})
+		if k == m-x.q {
+			break
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return len(parts[i].list) < len(parts[j].list) })
+
+candidates:
+	for _, p := range parts[0].list {
+		start := posting{p.doc, p.offset - parts[0].shift}
+		if start.offset < 0 || int(start.offset)+m > len(x.docs[start.doc]) {
+			continue
+		}
+		for _, o := range parts[1:] {
+			want := posting{start.doc, start.offset + o.shift}
+			i := sort.Search(len(o.list), func(i int) bool {
+				return o.list[i].doc > want.doc || (o.list[i].doc == want.doc && o.list[i].offset >= want.offset)
+			})
+			if i == len(o.list) || o.list[i] != want {
+				continue candidates
+			}
+		}
+		// verify with bmatch, so the configured dispatch searches the candidate window
+		window := x.docs[start.doc][start.offset : int(start.offset)+m]
+		if i, _ := bmatch.Index(&window, &needle); i == 0 && report(DocHit{int(start.doc), int(start.offset)}) {
+			return
+		}
+	}
+}
+
+// FindAll returns all (overlapping) matches of needle ordered by document and offset.
+func (x *QGramIndex) FindAll(needle *[]byte) (found []DocHit, e error) {
+	if len(*needle) < 1 {
+		return found, NEEDLESHORT
+	}
+	x.search(*needle, func(h DocHit) bool {
+		found = append(found, h)
+		return false
+	})
+	return found, nil
+}
+
+// Count returns the number of (overlapping) matches of needle in all documents.
+func (x *QGramIndex) Count(needle *[]byte) (count int, e error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	x.search(*needle, func(DocHit) bool {
+		count++
+		return false
+	})
+	return count, nil
+}
+
+// Index returns the first match of needle (lowest document id, then offset), or Doc -1 if there is none.
+func (x *QGramIndex) Index(needle *[]byte) (hit DocHit, e error) {
+	hit = DocHit{-1, -1}
+	if len(*needle) < 1 {
+		return hit, NEEDLESHORT
+	}
+	x.search(*needle, func(h DocHit) bool {
+		hit = h
+		return true
+	})
+	return hit, nil
+}
+
+// Save writes the index to path (see file.go); the documents are not saved.
+func (x *QGramIndex) Save(path string) error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	keys := make([]uint64, 0, len(x.postings))
+	for g := range x.postings {
+		keys = append(keys, g)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	counts := make([]int, len(keys))
+	var flat []posting
+	for k, g := range keys {
+		counts[k] = len(x.postings[g])
+		flat = append(flat, x.postings[g]...)
+	}
+
+	sum, n := corpusHash(x.docs...)
+	return save(path, header{kind: kindQGramIndex, corpusLen: n, corpusHash: sum}, func(en *encoder) {
+		en.u64(uint64(x.q))
+		en.u64(uint64(len(x.docs)))
+		en.uint64s(keys)
+		en.ints(counts)
+		en.postings(flat)
+	})
+}
+
+// LoadQGramIndex loads the index saved at path. docs must be the documents
+// in the order they were added, else LoadQGramIndex fails with CORPUSMISMATCH.
+// More documents may be added to the loaded index. On Linux the file is mapped until Close.
+func LoadQGramIndex(path string, docs [][]byte) (*QGramIndex, error) {
+	h, de, m, e := load(path, kindQGramIndex)
+	if e != nil {
+		return nil, e
+	}
+	if e = h.checkCorpus(corpusHash(docs...)); e != nil {
+		m.close()
+		return nil, e
+	}
+	x := &QGramIndex{q: de.int(), m: m}
+	ndocs := de.int()
+	keys := de.uint64s()
+	counts := de.ints()
+	flat := de.postings()
+	if de.e == nil && (x.q < 1 || x.q > 8 || ndocs != len(docs) || len(counts) != len(keys)) {
+		de.e = CORRUPT
+	}
+	if e = finish(de, m); e != nil {
+		return nil, e
+	}
+	x.docs = append([][]byte(nil), docs...)
+	x.postings = make(map[uint64][]posting, len(keys))
+	start := 0
+	for k, g := range keys {
+		end := start + counts[k]
+		if counts[k] < 0 || end > len(flat) {
+			m.close()
+			return nil, CORRUPT
+		}
+		// full slice: Add appends to a copy, not to the mapped file
+		x.postings[g] = flat[start:end:end]
+		start = end
+	}
+	return x, nil
+}
+
+// Close releases the file of LoadQGramIndex; the QGramIndex must not be used afterwards.
+// It is a no-op for indexes built by NewQGramIndex.
+func (x *QGramIndex) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.m.close()
+}
@@ -0,0 +1,150 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch"
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// docHitsRef returns the matches of needle in docs found by scanning
+func docHitsRef(docs [][]byte, needle []byte) (hits []DocHit) {
+	for d, doc := range docs {
+		for _, i := range testcorpus.FindAll(doc, needle) {
+			hits = append(hits, DocHit{d, i})
+		}
+	}
+	return hits
+}
+
+func TestQGramIndex_Corpora(t *testing.T) {
+	for _, q := range []int{0, 1, 2, 5, 8} {
+		x, e := NewQGramIndex(q)
+		if e != nil {
+			t.Fatal(e)
+		}
+		var docs [][]byte
+		for _, c := range testcorpus.All(3, 1<<14) {
+			// incremental: add the corpus in documents of varying length, query after each
+			for i, l := 0, 1; i < len(c.Data); i, l = i+l, l*3%1000+1 {
+				doc := c.Data[i:min(i+l, len(c.Data))]
+				id, e := x.Add(doc)
+				if e != nil || id != len(docs) {
+					t.Fatalf("Add = %d, %v; want %d", id, e, len(docs))
+				}
+				docs = append(docs, doc)
+			}
+			for _, m := range []int{1, 2, 3, 4, 9, 17} {
+				for _, needle := range testcorpus.Needles(int64(m), c.Data, m, 10) {
+					want := docHitsRef(docs, needle)
+					found, _ := x.FindAll(&needle)
+					count, _ := x.Count(&needle)
+					first, _ := x.Index(&needle)
+					if len(found) != len(want) || count != len(want) {
+						t.Fatalf("q %d needle %q: %d hits, count %d; want %d", q, needle, len(found), count, len(want))
+					}
+					for k := range found {
+						if found[k] != want[k] {
+							t.Fatalf("q %d needle %q: hit %d = %v; want %v", q, needle, k, found[k], want[k])
+						}
+					}
+					if len(want) > 0 && first != want[0] {
+						t.Fatalf("q %d needle %q: Index %v; want %v", q, needle, first, want[0])
+					}
+				}
+			}
+		}
+	}
+}
+
+// countingSearcher is bytes.Index counting its calls
+type countingSearcher struct{ calls int }
+
+func (s *countingSearcher) Index(haystack, needle *[]byte) (int, error) {
+	s.calls++
+	return bytes.Index(*haystack, *needle), nil
+}
+
+func (s *countingSearcher) Count(haystack, needle *[]byte) (int, error) {
+	s.calls++
+	return bytes.Count(*haystack, *needle), nil
+}
+
+func (s *countingSearcher) FindAll(haystack, needle *[]byte) ([]int, error) {
+	s.calls++
+	return testcorpus.FindAll(*haystack, *needle), nil
+}
+
+// the candidates are verified by the algorithm the dispatch table names
+func TestQGramIndex_VerifiesWithBmatch(t *testing.T) {
+	s := &countingSearcher{}
+	if e := bmatch.Register(bmatch.Algorithm{Name: "test-qgram", MinNeedle: 2, Searcher: s}); e != nil {
+		t.Fatal(e)
+	}
+	defer bmatch.Unregister("test-qgram")
+	saved := bmatch.DefaultProfile()
+	defer bmatch.SetDefaultProfile(saved)
+	if e := bmatch.SetDispatch(bmatch.Band{Below: 2, Algorithm: "memchr"}, bmatch.Band{Algorithm: "test-qgram"}); e != nil {
+		t.Fatal(e)
+	}
+
+	x, _ := NewQGramIndex(3)
+	x.Add([]byte("abracadabra"))
+	x.Add([]byte("cadabra abracad"))
+	needle := []byte("abracad")
+	found, _ := x.FindAll(&needle)
+	if len(found) != 2 || found[1] != (DocHit{1, 8}) {
+		t.Fatalf("FindAll = %v", found)
+	}
+	if s.calls != 2 {
+		t.Errorf("dispatched algorithm verified %d candidates; want 2", s.calls)
+	}
+}
+
+func TestQGramIndex_Errors(t *testing.T) {
+	if _, e := NewQGramIndex(9); e != BADQ {
+		t.Errorf("q 9: %v; want BADQ", e)
+	}
+	x, _ := NewQGramIndex(0)
+	if x.Q() != DefaultQ {
+		t.Errorf("default q %d; want %d", x.Q(), DefaultQ)
+	}
+	var needle []byte
+	if _, e := x.Count(&needle); e != NEEDLESHORT {
+		t.Errorf("empty needle: %v; want NEEDLESHORT", e)
+	}
+	needle = []byte("abc")
+	if h, _ := x.Index(&needle); h.Doc != -1 {
+		t.Errorf("empty index: Index %v", h)
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
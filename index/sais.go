@@ -0,0 +1,240 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * 'nos esse quasi nanos gigantum umeris insidentes' (Bernhard von Chartres, 1120)
+ * The giants in this respect:
+ * SA-IS builds the suffix array in linear time by induced sorting, published by
+ * G. Nong, S. Zhang and W. H. Chan (2009):
+ * Linear Suffix Array Construction by Almost Pure Induced-Sorting.
+ * Proceedings of the Data Compression Conference 2009, pp.193--202.
+ * The LCP array is computed from the permuted LCP (Φ) array after
+ * J. Kärkkäinen, G. Manzini and S. J. Puglisi (2009):
+ * Permuted Longest-Common-Prefix Array. CPM 2009, LNCS 5577, pp.181--192.
+ *
+ * The text has no sentinel: the empty suffix sorts first and is left out of the array.
+ */
+
+package index
+
+// empty marks free slots of the suffix array during the induced sorting
+const empty = -1
+
+// sais writes the suffix array of t (letters in [0,k)) to sa; len(sa) == len(t)
+func sais[T byte | int32](t []T, sa []int32, k int) {
+
+	var (
+		n   = len(t)
+		st  = make([]bool, n) // S-type suffixes
+		bkt = make([]int32, k)
+	)
+
+	switch n {
+	case 0:
+		return
+	case 1:
+		sa[0] = 0
+		return
+	}
+
+	// classify the suffixes; the last one is L-type (larger than the empty suffix)
+	for i := n - 2; i >= 0; i-- {
+		st[i] = t[i] < t[i+1] || (t[i] == t[i+1] && st[i+1])
+	}
+	isLMS := func(i int) bool {
+		return i > 0 && st[i] && !st[i-1]
+	}
+
+	// step 1: sort the LMS substrings by inducing from their unsorted LMS positions
+	for i := range sa {
+		sa[i] = empty
+	}
+	bucketEnds(t, bkt)
+	for i := 1; i < n; i++ {
+		if isLMS(i) {
+			bkt[t[i]]--
+			sa[bkt[t[i]]] = int32(i)
+		}
+	}
+	induce(t, sa, st, bkt)
+
+	// compact the sorted LMS positions to sa[:n1]
+	n1 := 0
+	for i := 0; i < n; i++ {
+		if isLMS(int(sa[i])) {
+			sa[n1] = sa[i]
+			n1++
+		}
+	}
+
+	// name the LMS substrings; equal substrings get equal names
+	for i := n1; i < n; i++ {
+		sa[i] = empty
+	}
+	name, prev := 0, -1
+	for i := 0; i < n1; i++ {
+		pos, diff := int(sa[i]), false
+		for d := 0; ; d++ {
+			if prev == -1 || pos+d == n || prev+d == n || t[pos+d] != t[prev+d] || st[pos+d] != st[prev+d] {
+				diff = true
+				break
+			}
+			if d > 0 && (isLMS(pos+d) || isLMS(prev+d)) {
+				break
+			}
+		}
+		if diff {
+			name++
+			prev = pos
+		}
+		sa[n1+pos/2] = int32(name - 1)
+	}
+	j := n - 1
+	for i := n - 1; i >= n1; i-- {
+		if sa[i] >= 0 {
+			sa[j] = sa[i]
+			j--
+		}
+	}
+
+	// step 2: sort the LMS suffixes; recurse if the names are not unique
+	var (
+		sa1 = sa[:n1]
+		s1  = sa[n-n1:]
+	)
+	if name < n1 {
+		sais(s1, sa1, name)
+	} else {
+		for i, c := range s1 {
+			sa1[c] = int32(i)
+		}
+	}
+
+	// step 3: induce the suffix array from the sorted LMS suffixes
+	j = 0
+	for i := 1; i < n; i++ {
+		if isLMS(i) {
+			s1[j] = int32(i)
+			j++
+		}
+	}
+	for i := range sa1 {
+		sa1[i] = s1[sa1[i]]
+	}
+	for i := n1; i < n; i++ {
+		sa[i] = empty
+	}
+	bucketEnds(t, bkt)
+	for i := n1 - 1; i >= 0; i-- {
+		j := sa[i]
+		sa[i] = empty
+		bkt[t[j]]--
+		sa[bkt[t[j]]] = j
+	}
+	induce(t, sa, st, bkt)
+}
+
+// induce sorts the L-type suffixes from the LMS suffixes in sa, then the S-type ones from the L-type
+func induce[T byte | int32](t []T, sa []int32, st []bool, bkt []int32) {
+	n := len(t)
+
+	bucketStarts(t, bkt)
+	// the last suffix follows the empty one, which sorts first
+	sa[bkt[t[n-1]]] = int32(n - 1)
+	bkt[t[n-1]]++
+	for i := 0; i < n; i++ {
+		if j := sa[i] - 1; j >= 0 && !st[j] {
+			sa[bkt[t[j]]] = j
+			bkt[t[j]]++
+		}
+	}
+
+	bucketEnds(t, bkt)
+	for i := n - 1; i >= 0; i-- {
+		if j := sa[i] - 1; j >= 0 && st[j] {
+			bkt[t[j]]--
+			sa[bkt[t[j]]] = j
+		}
+	}
+}
+
+func bucketStarts[T byte | int32](t []T, bkt []int32) {
+	counts(t, bkt)
+	var sum int32
+	for c, k := range bkt {
+		bkt[c] = sum
+		sum += k
+	}
+}
+
+func bucketEnds[T byte | int32](t []T, bkt []int32) {
+	counts(t, bkt)
+	var sum int32
+	for c, k := range bkt {
+		sum += k
+		bkt[c] = sum
+	}
+}
+
+func counts[T byte | int32](t []T, bkt []int32) {
+	for c := range bkt {
+		bkt[c] = 0
+	}
+	for _, c := range t {
+		bkt[c]++
+	}
+}
+
+// lcpArray returns lcp[i], the length of the longest common prefix
+// of the suffixes sa[i-1] and sa[i] (lcp[0] = 0)
+func lcpArray(t []byte, sa []int32) []int32 {
+	var (
+		n   = len(t)
+		phi = make([]int32, n) // phi[sa[i]] = sa[i-1], then the permuted LCP
+		lcp = make([]int32, n)
+		l   int
+	)
+	if n == 0 {
+		return lcp
+	}
+	phi[sa[0]] = empty
+	for i := 1; i < n; i++ {
+		phi[sa[i]] = sa[i-1]
+	}
+	for i := 0; i < n; i++ {
+		if phi[i] == empty {
+			phi[i], l = 0, 0
+			continue
+		}
+		for j := int(phi[i]); i+l < n && j+l < n && t[i+l] == t[j+l]; {
+			l++
+		}
+		phi[i] = int32(l)
+		if l > 0 {
+			l--
+		}
+	}
+	for i := 0; i < n; i++ {
+		lcp[i] = phi[sa[i]]
+	}
+	return lcp
+}
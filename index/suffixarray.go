@@ -0,0 +1,199 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * Package index holds full-text indexes over a static haystack for many queries:
+ * every query costs a search of the index instead of a scan of the haystack.
+ * The results of Index, Count and FindAll are those of bmatch.Index, bmatch.Count
+ * and bmatch.FindAll on the haystack (overlapping matches, ascending offsets).
+ *
+ * SuffixArray: suffix array (SA-IS) and LCP array, 8 bytes per haystack byte;
+ * Count in O(m log n), FindAll in O(m log n + occ log occ).
+ * FMIndex: FM-index on the Burrows-Wheeler transform in a wavelet matrix with sampled
+ * suffix array, (log2(alphabet size) + 4*32/rate) bits per haystack byte, i.e. below one
+ * byte for DNA; Count in O(m), FindAll in O(m + occ*rate) without keeping the haystack.
+ * QGramIndex: inverted index of the q-grams of many documents, documents may be added
+ * at any time; matches are DocHits (document, offset).
+ * All of them are saved to and loaded (mapped) from files with Save and Load* (see file.go).
+ */
+
+package index
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+)
+
+// Errors
+var (
+	NEEDLESHORT = errors.New("length of needle is smaller 1")
+	TOOLARGE    = errors.New("haystack is larger than 2GB")
+)
+
+// SuffixArray is a suffix array with LCP array over a haystack.
+// The haystack must not be changed while the SuffixArray is in use.
+// A SuffixArray is safe for concurrent use.
+type SuffixArray struct {
+	data []byte
+	sa   []int32
+	lcp  []int32
+	m    *mapping // file of LoadSuffixArray
+}
+
+// NewSuffixArray builds the suffix array and the LCP array of haystack in linear time.
+func NewSuffixArray(haystack []byte) (*SuffixArray, error) {
+	if len(haystack) > math.MaxInt32 {
+		return nil, TOOLARGE
+	}
+	s := &SuffixArray{data: haystack, sa: make([]int32, len(haystack))}
+	sais(haystack, s.sa, 256)
+	s.lcp = lcpArray(haystack, s.sa)
+	return s, nil
+}
+
+// Len returns the length of the haystack.
+func (s *SuffixArray) Len() int {
+	return len(s.data)
+}
+
+// Suffix returns the offset of the i-th smallest suffix of the haystack.
+func (s *SuffixArray) Suffix(i int) int {
+	return int(s.sa[i])
+}
+
+// LCP returns the length of the longest common prefix of the (i-1)-th and the i-th smallest suffix;
+// LCP(0) is 0. The largest LCP is the length of the longest repeat of the haystack.
+func (s *SuffixArray) LCP(i int) int {
+	return int(s.lcp[i])
+}
+
+// lookup returns the range [lo, hi) of suffixes starting with needle
+func (s *SuffixArray) lookup(needle []byte) (lo, hi int) {
+	var (
+		n = len(s.sa)
+		m = len(needle)
+	)
+	prefix := func(i int) []byte {
+		p := s.data[s.sa[i]:]
+		if len(p) > m {
+			p = p[:m]
+		}
+		return p
+	}
+	lo = sort.Search(n, func(i int) bool { return bytes.Compare(prefix(i), needle) >= 0 })
+	hi = lo + sort.Search(n-lo, func(i int) bool { return bytes.Compare(prefix(lo+i), needle) > 0 })
+	return lo, hi
+}
+
+// Index returns the first (left) index of needle in the haystack or -1 if not present.
+func (s *SuffixArray) Index(needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	lo, hi := s.lookup(*needle)
+	if lo == hi {
+		return -1, nil
+	}
+	first := s.sa[lo]
+	for _, i := range s.sa[lo+1 : hi] {
+		if i < first {
+			first = i
+		}
+	}
+	return int(first), nil
+}
+
+// Count returns the number of (overlapping) occurrences of needle in the haystack.
+func (s *SuffixArray) Count(needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	lo, hi := s.lookup(*needle)
+	return hi - lo, nil
+}
+
+// FindAll returns the indices of all (overlapping) occurrences of needle in ascending order.
+func (s *SuffixArray) FindAll(needle *[]byte) (found []int, e error) {
+	if len(*needle) < 1 {
+		return found, NEEDLESHORT
+	}
+	lo, hi := s.lookup(*needle)
+	if lo == hi {
+		return found, nil
+	}
+	found = make([]int, hi-lo)
+	for k, i := range s.sa[lo:hi] {
+		found[k] = int(i)
+	}
+	sort.Ints(found)
+	return found, nil
+}
+
+// LongestRepeat returns an offset and the length of the longest substring found
+// at least twice in the haystack (overlaps allowed); length 0 if no byte repeats.
+func (s *SuffixArray) LongestRepeat() (offset, length int) {
+	for i, l := range s.lcp {
+		if int(l) > length {
+			offset, length = int(s.sa[i]), int(l)
+		}
+	}
+	return offset, length
+}
+
+// Save writes the suffix array and the LCP array to path (see file.go); the haystack is not saved.
+func (s *SuffixArray) Save(path string) error {
+	sum, n := corpusHash(s.data)
+	return save(path, header{kind: kindSuffixArray, corpusLen: n, corpusHash: sum}, func(en *encoder) {
+		en.int32s(s.sa)
+		en.int32s(s.lcp)
+	})
+}
+
+// LoadSuffixArray loads the suffix array of haystack saved at path.
+// It fails with CORPUSMISMATCH if the file was saved for another haystack.
+// On Linux the file is mapped until Close.
+func LoadSuffixArray(path string, haystack []byte) (*SuffixArray, error) {
+	h, de, m, e := load(path, kindSuffixArray)
+	if e != nil {
+		return nil, e
+	}
+	if e = h.checkCorpus(corpusHash(haystack)); e != nil {
+		m.close()
+		return nil, e
+	}
+	s := &SuffixArray{data: haystack, sa: de.int32s(), lcp: de.int32s(), m: m}
+	if de.e == nil && (len(s.sa) != len(haystack) || len(s.lcp) != len(haystack)) {
+		de.e = CORRUPT
+	}
+	if e = finish(de, m); e != nil {
+		return nil, e
+	}
+	return s, nil
+}
+
+// Close releases the file of LoadSuffixArray; the SuffixArray must not be used afterwards.
+// It is a no-op for suffix arrays built by NewSuffixArray.
+func (s *SuffixArray) Close() error {
+	return s.m.close()
+}
@@ -0,0 +1,160 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package index
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch"
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// naiveSA sorts the suffixes by comparison
+func naiveSA(t []byte) []int {
+	sa := make([]int, len(t))
+	for i := range sa {
+		sa[i] = i
+	}
+	sort.Slice(sa, func(i, j int) bool { return bytes.Compare(t[sa[i]:], t[sa[j]:]) < 0 })
+	return sa
+}
+
+func TestSuffixArray_Construction(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	texts := [][]byte{
+		{}, []byte("a"), []byte("aa"), []byte("ab"), []byte("ba"),
+		[]byte("banana"), []byte("mississippi"), []byte("abracadabra"),
+		bytes.Repeat([]byte("a"), 100), bytes.Repeat([]byte("ab"), 50), bytes.Repeat([]byte("aab"), 40),
+		bytes.Repeat([]byte{255, 0}, 30),
+	}
+	for k := 0; k < 300; k++ {
+		text := make([]byte, rnd.Intn(200))
+		sigma := 1 + rnd.Intn(4)
+		if k%3 == 0 {
+			sigma = 256
+		}
+		for i := range text {
+			text[i] = byte(rnd.Intn(sigma))
+		}
+		texts = append(texts, text)
+	}
+	for _, text := range texts {
+		s, e := NewSuffixArray(text)
+		if e != nil {
+			t.Fatal(e)
+		}
+		want := naiveSA(text)
+		for i := range want {
+			if s.Suffix(i) != want[i] {
+				t.Fatalf("text %q: suffix %d = %d; want %d", text, i, s.Suffix(i), want[i])
+			}
+			l := 0
+			if i > 0 {
+				for a, b := text[want[i-1]:], text[want[i]:]; l < len(a) && l < len(b) && a[l] == b[l]; {
+					l++
+				}
+			}
+			if s.LCP(i) != l {
+				t.Fatalf("text %q: LCP(%d) = %d; want %d", text, i, s.LCP(i), l)
+			}
+		}
+	}
+}
+
+func TestSuffixArray_LongestRepeat(t *testing.T) {
+	text := []byte("the cat sat on the mat; the cat ran")
+	s, _ := NewSuffixArray(text)
+	offset, length := s.LongestRepeat()
+	if got := string(text[offset : offset+length]); got != "the cat " {
+		t.Errorf("LongestRepeat = %q; want %q", got, "the cat ")
+	}
+}
+
+// TestSuffixArray_Corpora compares the queries with bmatch on the test corpora
+func TestSuffixArray_Corpora(t *testing.T) {
+	for _, c := range testcorpus.All(1, 1<<16) {
+		s, e := NewSuffixArray(c.Data)
+		if e != nil {
+			t.Fatal(e)
+		}
+		for _, m := range []int{1, 2, 3, 8, 20, 100} {
+			for _, needle := range testcorpus.Needles(int64(m), c.Data, m, 20) {
+				want, _ := bmatch.FindAll(&c.Data, &needle)
+				found, _ := s.FindAll(&needle)
+				count, _ := s.Count(&needle)
+				idx, _ := s.Index(&needle)
+				wantIdx := -1
+				if len(want) > 0 {
+					wantIdx = want[0]
+				}
+				if count != len(want) || idx != wantIdx || len(found) != len(want) {
+					t.Fatalf("%s needle %q: count %d index %d found %d; want %d, %d", c.Name, needle, count, idx, len(found), len(want), wantIdx)
+				}
+				for k := range found {
+					if found[k] != want[k] {
+						t.Fatalf("%s needle %q: found %v; want %v", c.Name, needle, found, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSuffixArray_Errors(t *testing.T) {
+	s, _ := NewSuffixArray([]byte("abc"))
+	var needle []byte
+	if _, e := s.Count(&needle); e != NEEDLESHORT {
+		t.Errorf("empty needle: %v; want NEEDLESHORT", e)
+	}
+	needle = []byte("abcd")
+	if n, e := s.Count(&needle); n != 0 || e != nil {
+		t.Errorf("needle longer than haystack: %d, %v; want 0, nil", n, e)
+	}
+}
+
+func BenchmarkSuffixArray_Build(b *testing.B) {
+	data := testcorpus.Text(1, 1<<22)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		NewSuffixArray(data)
+	}
+}
+
+// BenchmarkSuffixArray_Count counts 1000 needles of 8 to 32 bytes
+func BenchmarkSuffixArray_Count(b *testing.B) {
+	data := testcorpus.Text(1, 1<<22)
+	s, _ := NewSuffixArray(data)
+	var needles [][]byte
+	for m := 8; m <= 32; m += 8 {
+		needles = append(needles, testcorpus.Needles(int64(m), data, m, 250)...)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k := range needles {
+			s.Count(&needles[k])
+		}
+	}
+}
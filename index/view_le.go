@@ -0,0 +1,64 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build !purego && (386 || amd64 || arm64 || ppc64le)
+
+package index
+
+import (
+	"unsafe"
+)
+
+// The view functions return the little endian arrays of a loaded index file in place,
+// if they are aligned, and decoded copies else.
+
+func aligned(b []byte, size uintptr) bool {
+	return uintptr(unsafe.Pointer(&b[0]))%size == 0
+}
+
+func viewInt32s(b []byte) []int32 {
+	if len(b) < 4 || !aligned(b, 4) {
+		return decodeInt32s(b)
+	}
+	return unsafe.Slice((*int32)(unsafe.Pointer(&b[0])), len(b)/4)
+}
+
+func viewUint32s(b []byte) []uint32 {
+	if len(b) < 4 || !aligned(b, 4) {
+		return decodeUint32s(b)
+	}
+	return unsafe.Slice((*uint32)(unsafe.Pointer(&b[0])), len(b)/4)
+}
+
+func viewUint64s(b []byte) []uint64 {
+	if len(b) < 8 || !aligned(b, 8) {
+		return decodeUint64s(b)
+	}
+	return unsafe.Slice((*uint64)(unsafe.Pointer(&b[0])), len(b)/8)
+}
+
+func viewPostings(b []byte) []posting {
+	if len(b) < 8 || !aligned(b, 4) {
+		return decodePostings(b)
+	}
+	return unsafe.Slice((*posting)(unsafe.Pointer(&b[0])), len(b)/8)
+}
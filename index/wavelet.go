@@ -0,0 +1,172 @@
+// go package index
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package index
+
+import (
+	"math/bits"
+)
+
+// bitVector is a bit sequence with rank support:
+// a count of the ones before every block of eight words, 1/16 bit per bit
+type bitVector struct {
+	n     int
+	words []uint64
+	super []uint32
+}
+
+func newBitVector(n int) *bitVector {
+	return &bitVector{n: n, words: make([]uint64, (n+63)>>6)}
+}
+
+func (b *bitVector) set(i int) {
+	b.words[i>>6] |= 1 << uint(i&63)
+}
+
+func (b *bitVector) get(i int) bool {
+	return b.words[i>>6]&(1<<uint(i&63)) != 0
+}
+
+// build counts the ones of the blocks; call after the last set
+func (b *bitVector) build() {
+	b.super = make([]uint32, len(b.words)>>3+1)
+	var ones uint32
+	for w, word := range b.words {
+		if w&7 == 0 {
+			b.super[w>>3] = ones
+		}
+		ones += uint32(bits.OnesCount64(word))
+	}
+	if len(b.words)&7 == 0 {
+		b.super[len(b.words)>>3] = ones
+	}
+}
+
+// rank1 returns the number of ones in [0, i)
+func (b *bitVector) rank1(i int) int {
+	w := i >> 6
+	r := int(b.super[w>>3])
+	for j := w &^ 7; j < w; j++ {
+		r += bits.OnesCount64(b.words[j])
+	}
+	if i&63 != 0 {
+		r += bits.OnesCount64(b.words[w] & (1<<uint(i&63) - 1))
+	}
+	return r
+}
+
+// size returns the bytes held
+func (b *bitVector) size() int {
+	return 8*len(b.words) + 4*len(b.super)
+}
+
+/*
+ * waveletMatrix holds a sequence of codes of L bits in L bit vectors:
+ * level l holds bit L-1-l of each code, ordered stably by the bits of the levels above
+ * (G. Navarro, F. Claude, A. Ordóñez (2015): The wavelet matrix. Information Systems 47, pp.15--32).
+ * Access and rank take L rank operations.
+ */
+type waveletMatrix struct {
+	levels []*bitVector
+	zeros  []int
+	starts []int // position of each code's range after the last level
+}
+
+func newWaveletMatrix(seq []byte, sigma int) *waveletMatrix {
+	var (
+		n   = len(seq)
+		L   = bits.Len(uint(sigma - 1))
+		cur = append([]byte(nil), seq...)
+		nxt = make([]byte, n)
+	)
+	if L == 0 {
+		L = 1
+	}
+	w := &waveletMatrix{levels: make([]*bitVector, L), zeros: make([]int, L)}
+	for l := 0; l < L; l++ {
+		bv := newBitVector(n)
+		shift := uint(L - 1 - l)
+		z := 0
+		for i, c := range cur {
+			if c>>shift&1 == 0 {
+				nxt[z] = c
+				z++
+			} else {
+				bv.set(i)
+			}
+		}
+		o := z
+		for _, c := range cur {
+			if c>>shift&1 != 0 {
+				nxt[o] = c
+				o++
+			}
+		}
+		bv.build()
+		w.levels[l], w.zeros[l] = bv, z
+		cur, nxt = nxt, cur
+	}
+	w.starts = make([]int, sigma)
+	for c := range w.starts {
+		w.starts[c] = w.descend(uint(c), 0)
+	}
+	return w
+}
+
+// descend follows the code c from position i down to the last level
+func (w *waveletMatrix) descend(c uint, i int) int {
+	L := uint(len(w.levels))
+	for l, bv := range w.levels {
+		if c>>(L-1-uint(l))&1 == 0 {
+			i -= bv.rank1(i)
+		} else {
+			i = w.zeros[l] + bv.rank1(i)
+		}
+	}
+	return i
+}
+
+// rank returns the number of codes c in [0, i)
+func (w *waveletMatrix) rank(c uint, i int) int {
+	return w.descend(c, i) - w.starts[c]
+}
+
+// accessRank returns the code at i and the number of equal codes in [0, i)
+func (w *waveletMatrix) accessRank(i int) (c uint, r int) {
+	for l, bv := range w.levels {
+		if bv.get(i) {
+			c = c<<1 | 1
+			i = w.zeros[l] + bv.rank1(i)
+		} else {
+			c <<= 1
+			i -= bv.rank1(i)
+		}
+	}
+	return c, i - w.starts[c]
+}
+
+func (w *waveletMatrix) size() (s int) {
+	for _, bv := range w.levels {
+		s += bv.size()
+	}
+	return s + 8*len(w.zeros) + 8*len(w.starts)
+}
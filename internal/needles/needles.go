@@ -0,0 +1,74 @@
+// go package needles
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * needles draws the needles bmatch.Calibrate and cmd/bmbench time the algorithms with.
+ */
+
+package needles
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// Errors
+var (
+	EMPTY = errors.New("no needles can be drawn from an empty haystack")
+)
+
+// Draw returns present needles of random length in [lo, hi] (at most len(hay)) drawn
+// from hay, followed by absent ones made by changing one byte of such a needle to the
+// largest byte hay lacks (without one the needle is most probably absent anyway).
+func Draw(rnd *rand.Rand, hay []byte, lo, hi, present, absent int) ([][]byte, error) {
+	if len(hay) == 0 {
+		return nil, EMPTY
+	}
+	var (
+		absentByte byte
+		seen       [256]bool
+	)
+	for _, c := range hay {
+		seen[c] = true
+	}
+	for c := 255; c >= 0; c-- {
+		if !seen[c] {
+			absentByte = byte(c)
+			break
+		}
+	}
+	needles := make([][]byte, 0, present+absent)
+	for k := 0; k < present+absent; k++ {
+		m := lo + rnd.Intn(hi-lo+1)
+		if m > len(hay) {
+			m = len(hay)
+		}
+		si := rnd.Intn(len(hay) - m + 1)
+		needle := make([]byte, m)
+		copy(needle, hay[si:si+m])
+		if k >= present {
+			needle[rnd.Intn(m)] = absentByte
+		}
+		needles = append(needles, needle)
+	}
+	return needles, nil
+}
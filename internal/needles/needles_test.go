@@ -0,0 +1,53 @@
+// go package needles
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package needles
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDraw(t *testing.T) {
+	hay := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog ", 20))
+	ns, e := Draw(rand.New(rand.NewSource(1)), hay, 3, 9, 10, 5)
+	if e != nil || len(ns) != 15 {
+		t.Fatalf("Draw = %d needles, %v", len(ns), e)
+	}
+	for k, needle := range ns {
+		if len(needle) < 3 || len(needle) > 9 {
+			t.Fatalf("needle %q has length %d", needle, len(needle))
+		}
+		if present := bytes.Contains(hay, needle); present != (k < 10) {
+			t.Fatalf("needle %d %q present = %v", k, needle, present)
+		}
+	}
+	// needles are cut to the haystack
+	if ns, e = Draw(rand.New(rand.NewSource(1)), []byte("ab"), 4, 4, 1, 1); e != nil || len(ns[0]) != 2 || string(ns[0]) != "ab" {
+		t.Fatalf("short haystack: %q, %v", ns, e)
+	}
+	if _, e = Draw(rand.New(rand.NewSource(1)), nil, 1, 1, 1, 0); e != EMPTY {
+		t.Fatalf("empty haystack: %v; want EMPTY", e)
+	}
+}
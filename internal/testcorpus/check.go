@@ -0,0 +1,178 @@
+// go package testcorpus
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package testcorpus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// corpus size of Check
+const checkSize = 1 << 14
+
+// needles per corpus and length
+const checkNeedles = 12
+
+// Check compares Index, Count and FindAll of s with bytes.Index on all corpora
+// for needles of minNeedle to maxNeedle bytes (maxNeedle 0: no limit), including the
+// haystack boundaries: needle at the start and the end and haystack == needle.
+func Check(t *testing.T, s registry.Searcher, minNeedle, maxNeedle int) {
+	for _, c := range All(1, checkSize) {
+		failed := 0
+		for _, m := range Lengths {
+			if m < minNeedle || maxNeedle > 0 && m > maxNeedle {
+				continue
+			}
+			for k, needle := range Needles(int64(m), c.Data, m, checkNeedles) {
+				hays := [][]byte{c.Data}
+				if k < 2 {
+					// short haystacks around the needle
+					hays = append(hays, needle, c.Data[:m+1], c.Data[len(c.Data)-m-1:], c.Data[:2*m+3])
+				}
+				for _, hay := range hays {
+					if e := Compare(s, hay, needle); e != nil {
+						t.Errorf("%s: haystack of %d bytes, needle %q: %v", c.Name, len(hay), abbrev(needle), e)
+						if failed++; failed > 5 {
+							t.Errorf("%s: too many errors", c.Name)
+							goto next
+						}
+					}
+				}
+			}
+		}
+	next:
+	}
+}
+
+// Compare returns the first difference of Index, Count and FindAll of s to bytes.Index.
+func Compare(s registry.Searcher, hay, needle []byte) (e error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	want := FindAll(hay, needle)
+	wantIdx := -1
+	if len(want) > 0 {
+		wantIdx = want[0]
+	}
+
+	idx, e := s.Index(&hay, &needle)
+	if e != nil {
+		return fmt.Errorf("Index: %v", e)
+	}
+	if idx != wantIdx {
+		return fmt.Errorf("Index = %d; want %d", idx, wantIdx)
+	}
+	count, e := s.Count(&hay, &needle)
+	if e != nil {
+		return fmt.Errorf("Count: %v", e)
+	}
+	if count != len(want) {
+		return fmt.Errorf("Count = %d; want %d", count, len(want))
+	}
+	found, e := s.FindAll(&hay, &needle)
+	if e != nil {
+		return fmt.Errorf("FindAll: %v", e)
+	}
+	if len(found) != len(want) {
+		return fmt.Errorf("FindAll found %d; want %d", len(found), len(want))
+	}
+	for i := range found {
+		if found[i] != want[i] {
+			return fmt.Errorf("FindAll[%d] = %d; want %d", i, found[i], want[i])
+		}
+	}
+	return nil
+}
+
+func abbrev(needle []byte) []byte {
+	if len(needle) > 20 {
+		return append(append([]byte{}, needle[:17]...), "..."...)
+	}
+	return needle
+}
+
+// Seed is a haystack and needle for the seed corpus of a fuzz target.
+type Seed struct {
+	Haystack, Needle []byte
+}
+
+// Seeds returns fuzz seeds at the boundaries of the algorithms: needles of 2, 3, 62, 63, 64
+// and 65 bytes (the word sized bit vectors of bs_fsbndm) at the start and the end of the
+// haystack, haystack == needle, periodic haystacks and near misses.
+func Seeds() (seeds []Seed) {
+	for _, m := range []int{2, 3, 62, 63, 64, 65} {
+		needle := Periodic(int64(m), m)
+		needle[m/2] = 'c'
+		miss := append([]byte{}, needle...)
+		miss[m-1] ^= 1
+		text := Text(int64(m), 3*m)
+		seeds = append(seeds,
+			Seed{needle, needle},
+			Seed{append(append([]byte{}, needle...), text...), needle},
+			Seed{append(append([]byte{}, text...), needle...), needle},
+			Seed{append(append(append([]byte{}, needle...), text...), needle...), needle},
+			Seed{append(append(append([]byte{}, miss...), needle[:m-1]...), miss...), needle},
+			Seed{Periodic(int64(m), 4*m), Periodic(int64(m), m)},
+			Seed{make([]byte, 2*m+1), make([]byte, m)},
+		)
+	}
+	return seeds
+}
+
+// Compiling adapts a registry.Compiler to registry.Searcher compiling the needle on
+// each call, i.e. to Check the searches of the compiled needles.
+func Compiling(c registry.Compiler) registry.Searcher {
+	return compiling{c}
+}
+
+type compiling struct {
+	c registry.Compiler
+}
+
+func (s compiling) Index(haystack, needle *[]byte) (int, error) {
+	c, e := s.c.Compile(*needle)
+	if e != nil {
+		return -1, e
+	}
+	return c.Index(haystack)
+}
+
+func (s compiling) Count(haystack, needle *[]byte) (int, error) {
+	c, e := s.c.Compile(*needle)
+	if e != nil {
+		return -1, e
+	}
+	return c.Count(haystack)
+}
+
+func (s compiling) FindAll(haystack, needle *[]byte) ([]int, error) {
+	c, e := s.c.Compile(*needle)
+	if e != nil {
+		return nil, e
+	}
+	return c.FindAll(haystack)
+}
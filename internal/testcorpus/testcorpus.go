@@ -0,0 +1,196 @@
+// go package testcorpus
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * testcorpus generates deterministic test corpora from a seed, so the tests of bmatch
+ * and its algorithm packages run offline and reproducible:
+ * English-like text (a character Markov chain of order 3), DNA, protein, random binary
+ * and adversarial periodic data (short periods with rare mutations - worst cases for
+ * the shift heuristics).
+ * Check runs a searcher over all corpora and compares its results with bytes.Index.
+ */
+
+package testcorpus
+
+import (
+	"bytes"
+	"math/rand"
+)
+
+// Corpus is a named test haystack.
+type Corpus struct {
+	Name string
+	Data []byte
+}
+
+// training text of the Markov chain
+const english = `It is a truth universally acknowledged that a good search routine must be fast on the
+common case and correct on every other one. The quick brown fox jumps over the lazy dog, while the
+population of the country was estimated at about four million people in the last census. The
+climate is temperate, with cold winters and warm summers; the terrain consists mostly of plains and
+low mountains in the north. Natural resources: coal, iron ore, timber, fish, arable land. Land use
+was divided between forests and meadows and permanent pastures. The government is a parliamentary
+democracy, the capital lies at the river, and the economy depends on trade, agriculture and
+services. Exports were machinery, chemicals, foodstuffs and textiles; imports were fuels, metals
+and consumer goods. Transportation includes railroads, highways, pipelines, ports and airports.
+Communications: telephone system is modern; radio and television broadcast stations are many.
+`
+
+// Text returns n bytes of English-like text generated by a character Markov chain of order 3.
+func Text(seed int64, n int) []byte {
+	const order = 3
+	var (
+		rnd   = rand.New(rand.NewSource(seed))
+		model = map[string][]byte{}
+		train = []byte(english)
+	)
+	for i := order; i < len(train); i++ {
+		k := string(train[i-order : i])
+		model[k] = append(model[k], train[i])
+	}
+	out := make([]byte, 0, n+order)
+	start := rnd.Intn(len(train) - order)
+	out = append(out, train[start:start+order]...)
+	for len(out) < n {
+		next := model[string(out[len(out)-order:])]
+		if len(next) == 0 {
+			start = rnd.Intn(len(train) - order)
+			out = append(out, train[start:start+order]...)
+			continue
+		}
+		out = append(out, next[rnd.Intn(len(next))])
+	}
+	return out[:n]
+}
+
+// DNA returns n random bases ACGT with some GC bias and short tandem repeats.
+func DNA(seed int64, n int) []byte {
+	rnd := rand.New(rand.NewSource(seed))
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		if rnd.Intn(200) == 0 {
+			// tandem repeat of a short unit
+			unit := out[len(out)-min(len(out), 1+rnd.Intn(6)):]
+			for k := rnd.Intn(10); k > 0 && len(unit) > 0; k-- {
+				out = append(out, unit...)
+			}
+			continue
+		}
+		out = append(out, "AACCCGGGTT"[rnd.Intn(10)])
+	}
+	return out[:n]
+}
+
+// amino acids weighted by their frequency in proteins (approximately)
+const aminoAcids = "AAAAAAAARRRRRNNNNDDDDDCCEEEEEEQQQQGGGGGGGHHIIIIIILLLLLLLLLLKKKKKKMMFFFFPPPPPSSSSSSSTTTTTWYYYVVVVVVV"
+
+// Protein returns n amino acid letters.
+func Protein(seed int64, n int) []byte {
+	rnd := rand.New(rand.NewSource(seed))
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = aminoAcids[rnd.Intn(len(aminoAcids))]
+	}
+	return out
+}
+
+// Binary returns n random bytes.
+func Binary(seed int64, n int) []byte {
+	rnd := rand.New(rand.NewSource(seed))
+	out := make([]byte, n)
+	rnd.Read(out)
+	return out
+}
+
+// Periodic returns n bytes repeating a short unit (period 1 to 5) over a two letter alphabet,
+// with one byte in a thousand mutated - needles from it match or almost match everywhere.
+func Periodic(seed int64, n int) []byte {
+	rnd := rand.New(rand.NewSource(seed))
+	unit := make([]byte, 1+rnd.Intn(5))
+	for i := range unit {
+		unit[i] = "ab"[rnd.Intn(2)]
+	}
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = unit[i%len(unit)]
+		if rnd.Intn(1000) == 0 {
+			out[i] = "abc"[rnd.Intn(3)]
+		}
+	}
+	return out
+}
+
+// All returns all kinds of corpora of n bytes each.
+func All(seed int64, n int) []Corpus {
+	return []Corpus{
+		{"text", Text(seed, n)},
+		{"dna", DNA(seed, n)},
+		{"protein", Protein(seed, n)},
+		{"binary", Binary(seed, n)},
+		{"periodic", Periodic(seed, n)},
+		{"zeros", make([]byte, n)},
+	}
+}
+
+// Lengths of needles checked: short ones and the boundaries of the word sized bit vectors.
+var Lengths = []int{1, 2, 3, 4, 5, 7, 8, 9, 15, 16, 17, 31, 32, 33, 62, 63, 64, 65, 100, 127, 128, 129, 257, 1000}
+
+// Needles returns count needles of length m drawn from data; every fourth needle has one byte
+// changed (and is then most probably absent). The first needles are the prefix and suffix of data.
+func Needles(seed int64, data []byte, m, count int) [][]byte {
+	rnd := rand.New(rand.NewSource(seed))
+	needles := make([][]byte, 0, count)
+	for k := 0; k < count && m <= len(data); k++ {
+		si := rnd.Intn(len(data) - m + 1)
+		switch k {
+		case 0:
+			si = 0
+		case 1:
+			si = len(data) - m
+		}
+		needle := append([]byte{}, data[si:si+m]...)
+		if k > 1 && k%4 == 3 {
+			needle[rnd.Intn(m)] ^= byte(1 + rnd.Intn(255))
+		}
+		needles = append(needles, needle)
+	}
+	return needles
+}
+
+// FindAll returns the indices of all (overlapping) occurrences of needle using bytes.Index.
+func FindAll(haystack, needle []byte) (found []int) {
+	for off := 0; ; {
+		idx := bytes.Index(haystack[off:], needle)
+		if idx < 0 {
+			return found
+		}
+		found = append(found, off+idx)
+		off += idx + 1
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
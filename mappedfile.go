@@ -0,0 +1,270 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime/debug"
+
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// Errors of MappedFile
+var (
+	TRUNCATED = errors.New("file was truncated while it was searched")
+)
+
+// size of the windows a MappedFile is searched in (rounded to pages)
+const mappedWindow = 64 << 20
+
+// MappedFile is a file searched without reading it into memory: it is mapped
+// read-only in windows of mappedWindow bytes overlapping by len(needle)-1 bytes
+// (on Linux, see mappedfile_linux.go), or streamed through a buffer of that size
+// where it can't be mapped. Offsets are int64 to search files larger than the address space.
+//
+// Reading a mapped page past the end of a file that was truncated after it was mapped
+// raises SIGBUS. The file's size is checked before each window is mapped, and a fault while
+// a window is searched is recovered; the search returns TRUNCATED in both cases.
+// Hits already handed to Search callbacks were found before the truncation.
+type MappedFile struct {
+	f       *os.File
+	size    int64
+	regular bool
+	window  int // multiple of the page size
+}
+
+// OpenMappedFile opens the file at path for searching.
+// Regular files may be searched concurrently; pipes and devices once.
+func OpenMappedFile(path string) (*MappedFile, error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, e
+	}
+	fi, e := f.Stat()
+	if e != nil {
+		f.Close()
+		return nil, e
+	}
+	return &MappedFile{f: f, size: fi.Size(), regular: fi.Mode().IsRegular(), window: mappedWindow}, nil
+}
+
+// SearchFile returns the offsets of all needles in the file at path.
+func SearchFile(path string, needle []byte) ([]int64, error) {
+	mf, e := OpenMappedFile(path)
+	if e != nil {
+		return nil, e
+	}
+	found, e := mf.FindAll(needle)
+	if ce := mf.Close(); e == nil {
+		e = ce
+	}
+	return found, e
+}
+
+// Size returns the size of the file; 0 for pipes and devices.
+func (mf *MappedFile) Size() int64 {
+	return mf.size
+}
+
+// Close closes the file.
+func (mf *MappedFile) Close() error {
+	return mf.f.Close()
+}
+
+// Search calls fn for the offsets of needle in ascending order until fn returns false.
+func (mf *MappedFile) Search(needle []byte, fn func(int64) bool) error {
+	var found []int64
+	return mf.scan(needle, func(cn registry.Compiled, data []byte, base int64) (bool, error) {
+		hits, e := cn.FindAll(&data)
+		found = found[:0]
+		for _, i := range hits {
+			found = append(found, base+int64(i))
+		}
+		return true, e
+	}, func() bool {
+		// the window is released; fn may panic or take its time
+		for _, i := range found {
+			if !fn(i) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// FindAll returns the offsets of all needles in the file.
+func (mf *MappedFile) FindAll(needle []byte) (found []int64, e error) {
+	e = mf.Search(needle, func(i int64) bool {
+		found = append(found, i)
+		return true
+	})
+	return found, e
+}
+
+// Index returns the offset of the first needle in the file, or -1.
+func (mf *MappedFile) Index(needle []byte) (idx int64, e error) {
+	idx = -1
+	e = mf.scan(needle, func(cn registry.Compiled, data []byte, base int64) (bool, error) {
+		i, e := cn.Index(&data)
+		if i >= 0 {
+			idx = base + int64(i)
+			return false, e
+		}
+		return true, e
+	}, nil)
+	return idx, e
+}
+
+// Count returns the number of needles in the file.
+func (mf *MappedFile) Count(needle []byte) (count int64, e error) {
+	e = mf.scan(needle, func(cn registry.Compiled, data []byte, base int64) (bool, error) {
+		k, e := cn.Count(&data)
+		count += int64(k)
+		return true, e
+	}, nil)
+	return count, e
+}
+
+// windowFunc searches the window data at file offset base; it returns false to stop.
+// It must not call user code: a mapped window is searched with faults turned into panics.
+type windowFunc func(cn registry.Compiled, data []byte, base int64) (bool, error)
+
+// scan hands the windows to search in file order. A window holds the m-1 bytes
+// following it as well; a needle starting in a window ends in it then, and no needle
+// is found twice. The algorithm is picked for the first window and compiled once.
+// after (if not nil) is called once a window is searched and released; it returns false to stop.
+func (mf *MappedFile) scan(needle []byte, search windowFunc, after func() bool) error {
+	m := len(needle)
+	if m < 1 {
+		return NEEDLESHORT
+	}
+	var cn registry.Compiled
+	visit := func(data []byte, base int64) (bool, error) {
+		if len(data) < m {
+			return false, nil
+		}
+		if cn == nil {
+			a, e := pick(data, m)
+			if e != nil {
+				return false, e
+			}
+			if cn, e = compile(a.Searcher, needle); e != nil {
+				return false, e
+			}
+		}
+		return search(cn, data, base)
+	}
+	// the streamed windows call after right away
+	visitAfter := func(data []byte, base int64) (bool, error) {
+		more, e := visit(data, base)
+		if e == nil && more && after != nil {
+			more = after()
+		}
+		return more, e
+	}
+
+	w := mf.window
+	if page := os.Getpagesize(); w < m {
+		w = (m + page - 1) / page * page
+	}
+	var off int64
+	if mf.regular {
+		for ; off < mf.size; off += int64(w) {
+			n := int64(w + m - 1)
+			if n > mf.size-off {
+				n = mf.size - off
+			}
+			if fi, e := mf.f.Stat(); e != nil {
+				return e
+			} else if fi.Size() < off+n {
+				return TRUNCATED
+			}
+			data, unmap, e := mapWindow(mf.f, off, int(n))
+			if e != nil {
+				// stream the rest
+				break
+			}
+			more, e := visitMapped(visit, data, off, unmap)
+			if e == nil && more && after != nil {
+				more = after()
+			}
+			if e != nil || !more {
+				return e
+			}
+		}
+		if off >= mf.size {
+			return nil
+		}
+		return stream(io.NewSectionReader(mf.f, off, mf.size-off), off, w, m, visitAfter)
+	}
+	return stream(mf.f, 0, w, m, visitAfter)
+}
+
+// visitMapped calls visit on the mapped window data and unmaps it, even if visit panics.
+// A fault reading data (the file was truncated meanwhile) returns TRUNCATED instead of
+// crashing the process with SIGBUS; the fault setting is restored before visitMapped returns.
+func visitMapped(visit func([]byte, int64) (bool, error), data []byte, base int64, unmap func() error) (more bool, e error) {
+	defer func() {
+		if ue := unmap(); e == nil {
+			e = ue
+		}
+	}()
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			if _, fault := r.(interface{ Addr() uintptr }); !fault {
+				panic(r)
+			}
+			more, e = false, TRUNCATED
+		}
+	}()
+	return visit(data, base)
+}
+
+// stream reads r through a buffer of w+m-1 bytes starting at file offset base,
+// keeping the last m-1 bytes of each window in front of the next
+func stream(r io.Reader, base int64, w, m int, visit func([]byte, int64) (bool, error)) error {
+	var (
+		buf  = make([]byte, w+m-1)
+		keep int
+	)
+	for {
+		n, e := io.ReadFull(r, buf[keep:])
+		switch e {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			_, e = visit(buf[:keep+n], base)
+			return e
+		default:
+			return e
+		}
+		more, e := visit(buf, base)
+		if e != nil || !more {
+			return e
+		}
+		keep = copy(buf, buf[len(buf)-(m-1):])
+		base += int64(len(buf) - keep)
+	}
+}
@@ -0,0 +1,45 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build linux
+
+package bmatch
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapWindow maps n bytes of f at the page aligned offset off read-only
+// and advises the kernel to read ahead; if it can't, the window is unmapped
+// and MappedFile streams the file instead
+func mapWindow(f *os.File, off int64, n int) ([]byte, func() error, error) {
+	data, e := syscall.Mmap(int(f.Fd()), off, n, syscall.PROT_READ, syscall.MAP_SHARED)
+	if e != nil {
+		return nil, nil, e
+	}
+	if e = syscall.Madvise(data, syscall.MADV_SEQUENTIAL); e != nil {
+		syscall.Munmap(data)
+		return nil, nil, e
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,214 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// windows of one page exercise the overlaps
+func testMappedFile(t *testing.T, data []byte) *MappedFile {
+	path := filepath.Join(t.TempDir(), "hay")
+	if e := os.WriteFile(path, data, 0o644); e != nil {
+		t.Fatal(e)
+	}
+	mf, e := OpenMappedFile(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t.Cleanup(func() { mf.Close() })
+	mf.window = os.Getpagesize()
+	return mf
+}
+
+func checkMapped(t *testing.T, mf *MappedFile, data, needle []byte) {
+	want := testcorpus.FindAll(data, needle)
+	found, e := mf.FindAll(needle)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(found) != len(want) {
+		t.Fatalf("FindAll(%q) found %d; want %d", needle, len(found), len(want))
+	}
+	for i := range found {
+		if found[i] != int64(want[i]) {
+			t.Fatalf("FindAll(%q)[%d] = %d; want %d", needle, i, found[i], want[i])
+		}
+	}
+	count, e := mf.Count(needle)
+	if e != nil || count != int64(len(want)) {
+		t.Fatalf("Count(%q) = %d, %v; want %d", needle, count, e, len(want))
+	}
+	idx, e := mf.Index(needle)
+	wantIdx := int64(-1)
+	if len(want) > 0 {
+		wantIdx = int64(want[0])
+	}
+	if e != nil || idx != wantIdx {
+		t.Fatalf("Index(%q) = %d, %v; want %d", needle, idx, e, wantIdx)
+	}
+}
+
+func TestMappedFile(t *testing.T) {
+	page := os.Getpagesize()
+	for _, c := range testcorpus.All(1, 5*page+17) {
+		mf := testMappedFile(t, c.Data)
+		for _, m := range []int{1, 2, 5, 33, 2*page + 3} {
+			needles := testcorpus.Needles(int64(m), c.Data, m, 2)
+			// needles across the window edges
+			for k := 1; k < 5; k++ {
+				if k*page < m/2 {
+					continue
+				}
+				needles = append(needles, c.Data[k*page-m/2:k*page-m/2+m])
+			}
+			for _, needle := range needles {
+				checkMapped(t, mf, c.Data, needle)
+			}
+		}
+	}
+}
+
+func TestMappedFile_Stream(t *testing.T) {
+	page := os.Getpagesize()
+	data := testcorpus.Text(2, 3*page+5)
+	r, w, e := os.Pipe()
+	if e != nil {
+		t.Fatal(e)
+	}
+	go func() {
+		w.Write(data)
+		w.Close()
+	}()
+	defer r.Close()
+	fi, _ := r.Stat()
+	mf := &MappedFile{f: r, window: page, regular: fi.Mode().IsRegular()}
+	needle := data[page-4 : page+4]
+	found, e := mf.FindAll(needle)
+	want := testcorpus.FindAll(data, needle)
+	if e != nil || len(found) != len(want) || found[0] != int64(want[0]) {
+		t.Fatalf("FindAll = %v, %v; want %v", found, e, want)
+	}
+
+	// the fallback on a regular file
+	var hits []int64
+	e = stream(io.NewSectionReader(testMappedFile(t, data).f, 0, int64(len(data))), 0, page, len(needle),
+		func(hay []byte, base int64) (bool, error) {
+			for _, i := range testcorpus.FindAll(hay, needle) {
+				hits = append(hits, base+int64(i))
+			}
+			return true, nil
+		})
+	if e != nil || len(hits) != len(want) || hits[0] != int64(want[0]) {
+		t.Fatalf("stream = %v, %v; want %v", hits, e, want)
+	}
+}
+
+func TestMappedFile_Truncated(t *testing.T) {
+	page := os.Getpagesize()
+	data := testcorpus.Text(3, 4*page)
+	mf := testMappedFile(t, data)
+	if e := os.Truncate(mf.f.Name(), int64(2*page)); e != nil {
+		t.Fatal(e)
+	}
+	if _, e := mf.Count([]byte("e")); e != TRUNCATED {
+		t.Fatalf("Count on truncated file: %v; want TRUNCATED", e)
+	}
+
+	// truncated while a mapped window is searched
+	mf = testMappedFile(t, data)
+	window, unmap, e := mapWindow(mf.f, 0, 2*page)
+	if e != nil {
+		t.Skip("no mmap:", e)
+	}
+	unmapped := false
+	_, e = visitMapped(func(hay []byte, _ int64) (bool, error) {
+		if e := os.Truncate(mf.f.Name(), 0); e != nil {
+			t.Fatal(e)
+		}
+		return hay[page] == 0, nil
+	}, window, 0, func() error {
+		unmapped = true
+		return unmap()
+	})
+	if e != TRUNCATED || !unmapped {
+		t.Fatalf("search of truncated window: %v, unmapped %v; want TRUNCATED, true", e, unmapped)
+	}
+}
+
+// the callback of Search runs after the window is released, with faults crashing as usual
+func TestMappedFile_SearchCallback(t *testing.T) {
+	data := testcorpus.Text(4, 3*os.Getpagesize())
+	mf := testMappedFile(t, data)
+	needle := []byte("e")
+	n := 0
+	e := mf.Search(needle, func(int64) bool {
+		if debug.SetPanicOnFault(false) {
+			t.Fatal("callback runs with panics on faults")
+		}
+		n++
+		return true
+	})
+	if want := len(testcorpus.FindAll(data, needle)); e != nil || n != want {
+		t.Fatalf("Search: %d hits, %v; want %d", n, e, want)
+	}
+
+	// a panicking callback leaves the fault setting alone
+	func() {
+		defer func() {
+			if r := recover(); r != "stop" {
+				t.Fatalf("recovered %v; want the callback's panic", r)
+			}
+		}()
+		mf.Search(needle, func(int64) bool { panic("stop") })
+	}()
+	if debug.SetPanicOnFault(false) {
+		t.Fatal("fault setting not restored after a panicking callback")
+	}
+}
+
+func TestSearchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hay")
+	if e := os.WriteFile(path, []byte("abcabcab"), 0o644); e != nil {
+		t.Fatal(e)
+	}
+	found, e := SearchFile(path, []byte("ab"))
+	if e != nil || len(found) != 3 || found[2] != 6 {
+		t.Fatalf("SearchFile = %v, %v", found, e)
+	}
+	if _, e = SearchFile(path, nil); e != NEEDLESHORT {
+		t.Fatalf("empty needle: %v", e)
+	}
+	if found, e = SearchFile(path, []byte("abcabcabc")); e != nil || found != nil {
+		t.Fatalf("needle longer than file: %v, %v", found, e)
+	}
+	if _, e = SearchFile(filepath.Join(t.TempDir(), "none"), []byte("ab")); e == nil {
+		t.Fatal("missing file: no error")
+	}
+}
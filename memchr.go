@@ -0,0 +1,62 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// memchr adapts the SWAR single byte functions of swarMEMCHR.go (and the vector kernel for Index, see simd.go) to registry.Searcher
+type memchr struct{}
+
+func (memchr) Index(haystack, needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	return indexByte(haystack, needle), nil
+}
+
+func (memchr) Count(haystack, needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	return mmCount(haystack, needle), nil
+}
+
+func (memchr) FindAll(haystack, needle *[]byte) (found []int, e error) {
+	if len(*needle) < 1 {
+		return found, NEEDLESHORT
+	}
+	return mmFindALL(haystack, needle), nil
+}
+
+// memchrAlgorithm is registered by the init() in dispatch.go,
+// before the dispatch table is compiled
+var memchrAlgorithm = Algorithm{
+	Name:      "memchr",
+	MinNeedle: 1,
+	MaxNeedle: 1,
+	WorstCase: registry.Linear,
+	Alphabet:  registry.AnyAlphabet,
+	Searcher:  memchr{},
+}
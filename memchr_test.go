@@ -0,0 +1,147 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// TestMemchr_Bounds searches every haystack length up to 40 at every word offset
+// of a larger buffer filled with the needle byte, so a read beyond the haystack shows up as a hit
+func TestMemchr_Bounds(t *testing.T) {
+	buf := bytes.Repeat([]byte{'x'}, 64)
+	needle := []byte{'x'}
+	for off := 0; off < 8; off++ {
+		for n := 1; n <= 40; n++ {
+			win := buf[off : off+n : off+n]
+			for k := range win {
+				win[k] = '.'
+			}
+			if got := mmIndex(&win, &needle); got != -1 {
+				t.Fatalf("off %d n %d: mmIndex %d on miss", off, n, got)
+			}
+			for k := range win {
+				win[k] = 'x'
+				if got := mmIndex(&win, &needle); got != k {
+					t.Fatalf("off %d n %d: mmIndex %d, want %d", off, n, got, k)
+				}
+				if got := mmCount(&win, &needle); got != 1 {
+					t.Fatalf("off %d n %d k %d: mmCount %d, want 1", off, n, k, got)
+				}
+				if got := mmFindALL(&win, &needle); len(got) != 1 || got[0] != k {
+					t.Fatalf("off %d n %d: mmFindALL %v, want [%d]", off, n, got, k)
+				}
+				win[k] = '.'
+			}
+			for k := range win {
+				win[k] = 'x'
+			}
+		}
+	}
+}
+
+// TestMemchr_AllBytes checks every byte value in runs of matches spanning the word boundaries
+func TestMemchr_AllBytes(t *testing.T) {
+	hay := make([]byte, 77)
+	for c := 0; c < 256; c++ {
+		for i := range hay {
+			hay[i] = byte(c + 1)
+			if i%5 == 0 || i%7 == 0 {
+				hay[i] = byte(c)
+			}
+		}
+		needle := []byte{byte(c)}
+		var want []int
+		for i := range hay {
+			if hay[i] == byte(c) {
+				want = append(want, i)
+			}
+		}
+		got := mmFindALL(&hay, &needle)
+		if len(got) != len(want) {
+			t.Fatalf("byte %d: mmFindALL %v, want %v", c, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("byte %d: mmFindALL %v, want %v", c, got, want)
+			}
+		}
+		if n := mmCount(&hay, &needle); n != len(want) {
+			t.Fatalf("byte %d: mmCount %d, want %d", c, n, len(want))
+		}
+		if i := mmIndex(&hay, &needle); i != want[0] {
+			t.Fatalf("byte %d: mmIndex %d, want %d", c, i, want[0])
+		}
+	}
+}
+
+// TestMemchr_BitScan checks the little and big endian bit scans on the same words,
+// so the big endian path is tested on little endian machines too
+func TestMemchr_BitScan(t *testing.T) {
+	word := []byte("a.a..aa.")
+	var want []int
+	for i, c := range word {
+		if c == 'a' {
+			want = append(want, i)
+		}
+	}
+	mask := ones * uint64('a')
+	for _, tc := range []struct {
+		name       string
+		z          uint64
+		firstByte  func(uint64) int
+		clearFirst func(uint64) uint64
+	}{
+		{"LE", zeroBytes(binary.LittleEndian.Uint64(word) ^ mask), firstByteLE, clearFirstLE},
+		{"BE", zeroBytes(binary.BigEndian.Uint64(word) ^ mask), firstByteBE, clearFirstBE},
+	} {
+		var got []int
+		for z := tc.z; z != 0; z = tc.clearFirst(z) {
+			got = append(got, tc.firstByte(z))
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: %v, want %v", tc.name, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s: %v, want %v", tc.name, got, want)
+			}
+		}
+	}
+}
+
+// TestMemchr_ZeroBytes checks zeroBytes marks exactly the zero bytes, also next to 0x01 and 0x80 bytes
+func TestMemchr_ZeroBytes(t *testing.T) {
+	for _, x := range []uint64{0, 0x0100010001000100, 0x8000800080008000, 0x0001000100010001, 0xff00ff00ff00ff00, 0x0101010101010100} {
+		z := zeroBytes(x)
+		for b := uint(0); b < 64; b += 8 {
+			isZero := x>>b&0xff == 0
+			marked := z>>b&0xff == 0x80
+			if isZero != marked || (!marked && z>>b&0xff != 0) {
+				t.Fatalf("zeroBytes(%#x) = %#x", x, z)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Position locates a match in the lines of a text.
+type Position struct {
+	Offset     int // byte offset of the match
+	Line       int // line number, from 1
+	Column     int // byte column, from 1
+	RuneColumn int // column in UTF-8 runes, from 1
+	LineStart  int // offset of the first byte of the line
+	LineEnd    int // offset of the line's '\n' (or len(haystack) on the last line)
+}
+
+var newline = []byte{'\n'}
+
+// FindAllPositions returns the Position of all matches of needle in haystack.
+// Lines end with '\n'; a match spanning lines is located on the line it starts on.
+// The newlines between matches are counted with the SWAR memchr (mmCount), so the
+// text between matches is not walked byte by byte.
+func FindAllPositions(haystack, needle *[]byte) (positions []Position, e error) {
+
+	found, e := FindAll(haystack, needle)
+	if e != nil || len(found) == 0 {
+		return positions, e
+	}
+
+	var (
+		hay       = *haystack
+		line      = 1
+		lineStart = 0
+		lineEnd   = -1 // not known yet
+		last      = 0  // offset up to which the newlines are counted
+		runeCol   = 1  // rune column of last
+	)
+
+	positions = make([]Position, len(found))
+	for i, off := range found {
+		if between := hay[last:off]; len(between) > 0 {
+			if nl := mmCount(&between, &newline); nl > 0 {
+				line += nl
+				lineStart = last + bytes.LastIndexByte(between, '\n') + 1
+				lineEnd = -1
+				runeCol = 1 + utf8.RuneCount(hay[lineStart:off])
+			} else {
+				runeCol += utf8.RuneCount(between)
+			}
+		}
+		if lineEnd < 0 {
+			if lineEnd = bytes.IndexByte(hay[off:], '\n'); lineEnd < 0 {
+				lineEnd = len(hay)
+			} else {
+				lineEnd += off
+			}
+		}
+		positions[i] = Position{
+			Offset:     off,
+			Line:       line,
+			Column:     off - lineStart + 1,
+			RuneColumn: runeCol,
+			LineStart:  lineStart,
+			LineEnd:    lineEnd,
+		}
+		last = off
+	}
+
+	return positions, nil
+}
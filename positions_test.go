@@ -0,0 +1,79 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+)
+
+// referencePositions locates the matches of bytes.Index line by line
+func referencePositions(hay, needle []byte) (positions []Position) {
+	found, _ := bytesIndexFindAll(&hay, &needle)
+	for _, off := range found {
+		start := bytes.LastIndexByte(hay[:off], '\n') + 1
+		end := bytes.IndexByte(hay[off:], '\n')
+		if end < 0 {
+			end = len(hay)
+		} else {
+			end += off
+		}
+		positions = append(positions, Position{
+			Offset:     off,
+			Line:       1 + bytes.Count(hay[:off], []byte{'\n'}),
+			Column:     off - start + 1,
+			RuneColumn: 1 + utf8.RuneCount(hay[start:off]),
+			LineStart:  start,
+			LineEnd:    end,
+		})
+	}
+	return positions
+}
+
+func TestFindAllPositions(t *testing.T) {
+	r := rand.New(rand.NewSource(36))
+	words := []string{"ab", "ba", "aab", "\n", "\n\n", "äb", "日本", " ", "b\na"}
+	for k := 0; k < 50; k++ {
+		var text []byte
+		for len(text) < 20+k*40 {
+			text = append(text, words[r.Intn(len(words))]...)
+		}
+		// start at odd offsets of the buffer
+		text = text[k%8:]
+		for _, needle := range []string{"a", "\n", "b", "ab", "aab", "b\na", "日", "äba", "ab ab"} {
+			hay, pat := text, []byte(needle)
+			want := referencePositions(hay, pat)
+			got, e := FindAllPositions(&hay, &pat)
+			if e != nil || len(got) != len(want) {
+				t.Fatalf("FindAllPositions(%q) = %d positions, %v; want %d", needle, len(got), e, len(want))
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("FindAllPositions(%q) in %q: %+v; want %+v", needle, hay, got[i], want[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+// Built-in profiles; the exported functions below hand out copies of them.
+var (
+	// switch points found optimal on natural text
+	// ("1995 CIA World Factbook", ~3MB, alphabet size 93).
+	// "simd" is the generic SIMD filter on CPUs with vector kernels (see simd.go), epsm and bs_fsbndm else
+	naturalTextProfile = &Profile{
+		Name: "NaturalText",
+		Bands: []Band{
+			{2, "memchr"},
+			{4, "epsm"},
+			{simdMaxNeedle + 1, "simd"},
+			{12000, "bhsearch"},
+			{350000, "bh2search"},
+			{0, "bs_fsbndm"},
+		},
+	}
+
+	// 4 letter nucleotide sequences; long needles profit from
+	// the Hash-q on 2 bit nucleotide codes. epsm and simd crossover at 4 bytes,
+	// simd and bhsearch at 16 (BenchmarkCrossover)
+	dnaProfile = &Profile{
+		Name: "DNA",
+		Bands: []Band{
+			{2, "memchr"},
+			{4, "epsm"},
+			{16, "simd"},
+			{128, "bhsearch"},
+			{0, "bhsearch-dna"},
+		},
+	}
+
+	// switch points found optimal for 23 amino-acid protein sequences
+	proteinProfile = &Profile{
+		Name: "Protein",
+		Bands: []Band{
+			{2, "memchr"},
+			{simdMaxNeedle + 1, "simd"},
+			{3000, "bs_fsbndm"},
+			{16000, "bhsearch"},
+			{350000, "bh2search"},
+			{1 << 22, "bcjsearch"},
+			{0, "bs_fsbndm"},
+		},
+	}
+
+	// (near) random bytes, i.e. compressed or encrypted data & firmware images;
+	// the hash based algorithms do not pay off on 256 letters
+	binaryProfile = &Profile{
+		Name: "Binary",
+		Bands: []Band{
+			{2, "memchr"},
+			{simdMaxNeedle + 1, "simd"},
+			{0, "bs_fsbndm"},
+		},
+	}
+)
+
+// NaturalText returns a copy of the profile for natural text, the default profile.
+func NaturalText() *Profile {
+	return naturalTextProfile.clone()
+}
+
+// DNA returns a copy of the profile for nucleotide sequences.
+func DNA() *Profile {
+	return dnaProfile.clone()
+}
+
+// Protein returns a copy of the profile for amino-acid sequences.
+func Protein() *Profile {
+	return proteinProfile.clone()
+}
+
+// Binary returns a copy of the profile for (near) random bytes.
+func Binary() *Profile {
+	return binaryProfile.clone()
+}
+
+// Profiles returns copies of the built-in profiles.
+func Profiles() []*Profile {
+	return []*Profile{NaturalText(), DNA(), Protein(), Binary()}
+}
+
+// IndexWith is Index using the profile p instead of the default profile.
+func IndexWith(p *Profile, haystack, needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	if p == nil {
+		return -1, NOPROFILE
+	}
+	a, e := p.pick(*haystack, len(*needle))
+	if e != nil {
+		return -1, e
+	}
+	return a.Searcher.Index(haystack, needle)
+}
+
+// CountWith is Count using the profile p instead of the default profile.
+func CountWith(p *Profile, haystack, needle *[]byte) (int, error) {
+	if len(*needle) < 1 {
+		return -1, NEEDLESHORT
+	}
+	if p == nil {
+		return -1, NOPROFILE
+	}
+	a, e := p.pick(*haystack, len(*needle))
+	if e != nil {
+		return -1, e
+	}
+	return a.Searcher.Count(haystack, needle)
+}
+
+// FindAllWith is FindAll using the profile p instead of the default profile.
+func FindAllWith(p *Profile, haystack, needle *[]byte) (found []int, e error) {
+	if len(*needle) < 1 {
+		return found, NEEDLESHORT
+	}
+	if p == nil {
+		return found, NOPROFILE
+	}
+	a, e := p.pick(*haystack, len(*needle))
+	if e != nil {
+		return found, e
+	}
+	return a.Searcher.FindAll(haystack, needle)
+}
+
+// Matcher searches using its own profile.
+// It implements Searcher and is safe for concurrent use.
+type Matcher struct {
+	profile *Profile
+}
+
+// NewMatcher returns a Matcher using a copy of profile p.
+func NewMatcher(p *Profile) (*Matcher, error) {
+	if e := p.Validate(); e != nil {
+		return nil, e
+	}
+	return &Matcher{p.clone()}, nil
+}
+
+// Profile returns a copy of the matcher's profile.
+func (mt *Matcher) Profile() *Profile {
+	return mt.profile.clone()
+}
+
+func (mt *Matcher) Index(haystack, needle *[]byte) (int, error) {
+	return IndexWith(mt.profile, haystack, needle)
+}
+
+func (mt *Matcher) Count(haystack, needle *[]byte) (int, error) {
+	return CountWith(mt.profile, haystack, needle)
+}
+
+func (mt *Matcher) FindAll(haystack, needle *[]byte) ([]int, error) {
+	return FindAllWith(mt.profile, haystack, needle)
+}
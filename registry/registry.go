@@ -0,0 +1,196 @@
+// go package registry
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * registry holds the search algorithms known to bmatch.
+ * Every algorithm package registers itself from its init() function with
+ * the metadata bmatch needs to route a needle to it. The registry lives in
+ * its own package, so that the algorithm packages can register without
+ * importing bmatch (which imports them).
+ * Your own algorithm is registered the same way and may then be named in
+ * bmatch's dispatch table.
+ */
+
+package registry
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Searcher is the interface every registered algorithm implements.
+// The methods follow the signatures of bmatch.Index, bmatch.Count and bmatch.FindAll.
+type Searcher interface {
+	Index(haystack, needle *[]byte) (int, error)
+	Count(haystack, needle *[]byte) (int, error)
+	FindAll(haystack, needle *[]byte) ([]int, error)
+}
+
+// Compiler is implemented by algorithms that preprocess a needle once
+// to search it in many haystacks (see bmatch.Corpus).
+type Compiler interface {
+	Compile(needle []byte) (Compiled, error)
+}
+
+// Compiled is a needle preprocessed by a Compiler.
+// It is safe for concurrent use.
+type Compiled interface {
+	Index(haystack *[]byte) (int, error)
+	Count(haystack *[]byte) (int, error)
+	FindAll(haystack *[]byte) ([]int, error)
+}
+
+// WorstCase classifies the worst case running time of an algorithm
+// for a haystack of length n and a needle of length m.
+type WorstCase int
+
+const (
+	Linear    WorstCase = iota // O(n)
+	Quadratic                  // O(n*m)
+)
+
+func (w WorstCase) String() string {
+	switch w {
+	case Linear:
+		return "O(n)"
+	case Quadratic:
+		return "O(n*m)"
+	}
+	return "unknown"
+}
+
+// AlphabetSuitability tells for which alphabet sizes an algorithm performs well.
+type AlphabetSuitability int
+
+const (
+	AnyAlphabet   AlphabetSuitability = iota
+	SmallAlphabet                     // i.e. DNA, protein sequences (< 30 letters)
+	LargeAlphabet                     // i.e. natural text, binary data
+)
+
+func (a AlphabetSuitability) String() string {
+	switch a {
+	case AnyAlphabet:
+		return "any"
+	case SmallAlphabet:
+		return "small"
+	case LargeAlphabet:
+		return "large"
+	}
+	return "unknown"
+}
+
+// Algorithm describes a registered search algorithm.
+// MinNeedle is the minimum needle length the algorithm handles,
+// MaxNeedle the maximum one (0 = no limit).
+type Algorithm struct {
+	Name      string
+	MinNeedle int
+	MaxNeedle int
+	WorstCase WorstCase
+	Alphabet  AlphabetSuitability
+	Searcher  Searcher
+}
+
+// Accepts reports whether a needle of length m is within the algorithm's limits.
+func (a Algorithm) Accepts(m int) bool {
+	return m >= a.MinNeedle && (a.MaxNeedle == 0 || m <= a.MaxNeedle)
+}
+
+// Errors
+var (
+	NONAME       = errors.New("Algorithm has no name")
+	NOSEARCHER   = errors.New("Algorithm has no Searcher")
+	BADLIMITS    = errors.New("Algorithm needle limits are invalid")
+	DUPLICATE    = errors.New("Algorithm is already registered")
+	UNREGISTERED = errors.New("Algorithm is not registered")
+)
+
+var (
+	mu         sync.RWMutex
+	algorithms = map[string]Algorithm{}
+)
+
+// Register adds an algorithm to the registry.
+// Names are unique; registering a name twice fails.
+func Register(a Algorithm) error {
+	switch {
+	case a.Name == "":
+		return NONAME
+	case a.Searcher == nil:
+		return NOSEARCHER
+	case a.MinNeedle < 1 || (a.MaxNeedle != 0 && a.MaxNeedle < a.MinNeedle):
+		return BADLIMITS
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := algorithms[a.Name]; ok {
+		return DUPLICATE
+	}
+	algorithms[a.Name] = a
+	return nil
+}
+
+// MustRegister is Register, but panics on error.
+// It is meant to be called from init() functions.
+func MustRegister(a Algorithm) {
+	if err := Register(a); err != nil {
+		panic("registry: " + a.Name + ": " + err.Error())
+	}
+}
+
+// Unregister removes the algorithm registered by name, i.e. one registered by a test.
+// Profiles naming it fail to pick it from then on.
+func Unregister(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := algorithms[name]; !ok {
+		return UNREGISTERED
+	}
+	delete(algorithms, name)
+	return nil
+}
+
+// Lookup returns the algorithm registered by name.
+func Lookup(name string) (Algorithm, error) {
+	mu.RLock()
+	a, ok := algorithms[name]
+	mu.RUnlock()
+	if !ok {
+		return a, UNREGISTERED
+	}
+	return a, nil
+}
+
+// All returns all registered algorithms sorted by name.
+func All() []Algorithm {
+	mu.RLock()
+	all := make([]Algorithm, 0, len(algorithms))
+	for _, a := range algorithms {
+		all = append(all, a)
+	}
+	mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
@@ -0,0 +1,174 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+
+	"github.com/AndreasBriese/bmatch/bs_fsbndm"
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// simdKernels are the vector kernels of the CPU (see simd_amd64.go).
+// width is the number of windows (bytes) compared at once;
+// the kernels need at least Width windows in the haystack.
+type simdKernels struct {
+	name      string
+	width     int
+	index     func(hay, needle []byte, from int) int
+	indexByte func(hay []byte, c byte) int
+}
+
+// simd holds the kernels picked at init; width 0 without any
+var simd simdKernels
+
+// simdMaxNeedle is the longest needle of the "simd" algorithm: beyond
+// the first/last byte filter lets pass too few windows to beat bs_fsbndm's shifts
+const simdMaxNeedle = 64
+
+// SIMD returns the name of the vector kernels used on this CPU ("avx2", "sse2"),
+// or "" if the "simd" algorithm falls back to epsm and bs_fsbndm and memchr to the SWAR functions.
+func SIMD() string {
+	return simd.name
+}
+
+// simdIndex returns the index of the first needle at or after from in hay, or -1
+func simdIndex(hay, needle []byte, from int) int {
+	if len(hay)-len(needle)+1 < simd.width {
+		if i := bytes.Index(hay[from:], needle); i >= 0 {
+			return from + i
+		}
+		return -1
+	}
+	return simd.index(hay, needle, from)
+}
+
+// indexByte is mmIndex on the vector kernel if there is one
+func indexByte(haystack, needle *[]byte) int {
+	if simd.width == 0 || len(*haystack) < simd.width {
+		return mmIndex(haystack, needle)
+	}
+	pat := *needle
+	return simd.indexByte(*haystack, pat[len(pat)-1])
+}
+
+// simdSearcher is the generic SIMD first/last byte filter (simd_amd64.s) for needles of
+// 2 to simdMaxNeedle bytes. Without vector kernels it is epsm up to 8 bytes, bs_fsbndm beyond.
+type simdSearcher struct{}
+
+func (simdSearcher) Index(haystack, needle *[]byte) (int, error) {
+	if simd.width == 0 {
+		return simdFallback(needle).Index(haystack, needle)
+	}
+	if e := simdCheck(haystack, needle); e != nil {
+		return -1, e
+	}
+	return simdIndex(*haystack, *needle, 0), nil
+}
+
+func (simdSearcher) Count(haystack, needle *[]byte) (count int, e error) {
+	if simd.width == 0 {
+		return simdFallback(needle).Count(haystack, needle)
+	}
+	if e = simdCheck(haystack, needle); e != nil {
+		return -1, e
+	}
+	for i := simdIndex(*haystack, *needle, 0); i >= 0; i = simdIndex(*haystack, *needle, i+1) {
+		count++
+	}
+	return count, nil
+}
+
+func (simdSearcher) FindAll(haystack, needle *[]byte) (found []int, e error) {
+	if simd.width == 0 {
+		return simdFallback(needle).FindAll(haystack, needle)
+	}
+	if e = simdCheck(haystack, needle); e != nil {
+		return found, e
+	}
+	for i := simdIndex(*haystack, *needle, 0); i >= 0; i = simdIndex(*haystack, *needle, i+1) {
+		found = append(found, i)
+	}
+	return found, nil
+}
+
+// Compile implements registry.Compiler: the vector kernels and epsm have no preprocessing,
+// the bs_fsbndm fallback builds its bit table once
+func (simdSearcher) Compile(needle []byte) (registry.Compiled, error) {
+	if simd.width == 0 && len(needle) > epsmMaxNeedle {
+		return bsfSearcher{}.Compile(needle)
+	}
+	return &uncompiled{simdSearcher{}, append([]byte(nil), needle...)}, nil
+}
+
+// simdFallback returns the Go searcher for the needle
+func simdFallback(needle *[]byte) Searcher {
+	if len(*needle) <= epsmMaxNeedle {
+		return epsm{}
+	}
+	return bsfSearcher{}
+}
+
+// bsfSearcher adapts the bs_fsbndm functions to Searcher
+type bsfSearcher struct{}
+
+func (bsfSearcher) Index(haystack, needle *[]byte) (int, error) {
+	return bs_fsbndm.Index(haystack, needle)
+}
+
+func (bsfSearcher) Count(haystack, needle *[]byte) (int, error) {
+	return bs_fsbndm.Count(haystack, needle)
+}
+
+func (bsfSearcher) FindAll(haystack, needle *[]byte) ([]int, error) {
+	return bs_fsbndm.FindAll(haystack, needle)
+}
+
+func (bsfSearcher) Compile(needle []byte) (registry.Compiled, error) {
+	c, e := bs_fsbndm.Compile(needle)
+	if e != nil {
+		return nil, e
+	}
+	return c, nil
+}
+
+// simdCheck returns bs_fsbndm's errors for the same needles
+func simdCheck(haystack, needle *[]byte) error {
+	switch {
+	case len(*haystack) < len(*needle):
+		return bs_fsbndm.NEEDLELONG
+	case len(*needle) < 2:
+		return bs_fsbndm.NEEDLESHORT
+	}
+	return nil
+}
+
+// simdAlgorithm is registered by the init() in dispatch.go
+var simdAlgorithm = Algorithm{
+	Name:      "simd",
+	MinNeedle: 2,
+	MaxNeedle: simdMaxNeedle,
+	WorstCase: registry.Quadratic,
+	Alphabet:  registry.AnyAlphabet,
+	Searcher:  simdSearcher{},
+}
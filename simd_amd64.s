@@ -0,0 +1,323 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build amd64 && !purego
+
+/*
+ * Generic SIMD substring search ("SIMD-friendly algorithms for substring searching",
+ * Wojciech Muła, 2016): a window is a candidate if its first and its last byte match;
+ * both are compared for 16 (SSE2) or 32 (AVX2) windows at once and only candidates are
+ * compared byte by byte.
+ * The callers guarantee at least one block of windows: len(hay)-len(needle)+1 >= 16 (32).
+ * The last block overlaps the one before; windows already searched are masked off,
+ * so no byte beyond the haystack is read.
+ */
+
+#include "textflag.h"
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
+
+// func indexSSE2(hay, needle []byte, from int) int
+TEXT ·indexSSE2(SB), NOSPLIT, $0-64
+	MOVQ hay_base+0(FP), SI
+	MOVQ hay_len+8(FP), DX
+	MOVQ needle_base+24(FP), DI
+	MOVQ needle_len+32(FP), R8
+	MOVQ from+48(FP), AX
+
+	// broadcast first and last byte of the needle
+	MOVQ $0x0101010101010101, R12
+	MOVBQZX (DI), R10
+	IMULQ R12, R10
+	MOVQ R10, X0
+	PUNPCKLQDQ X0, X0
+	MOVBQZX -1(DI)(R8*1), R10
+	IMULQ R12, R10
+	MOVQ R10, X1
+	PUNPCKLQDQ X1, X1
+
+	DECQ R8          // k-1: distance first to last byte
+	MOVQ DX, R9
+	SUBQ R8, R9      // n-k+1 windows
+	SUBQ $16, R9     // start of the last block
+	XORQ CX, CX      // CX != 0 in the last block
+
+sse2loop:
+	CMPQ AX, R9
+	JGT  sse2last
+	LEAQ (SI)(AX*1), R11
+	MOVOU (R11), X2
+	PCMPEQB X0, X2
+	MOVOU (R11)(R8*1), X3
+	PCMPEQB X1, X3
+	PAND X2, X3
+	PMOVMSKB X3, BX
+	TESTL BX, BX
+	JNZ sse2verify
+
+sse2next:
+	TESTQ CX, CX
+	JNZ sse2notfound
+	ADDQ $16, AX
+	JMP sse2loop
+
+sse2last:
+	MOVQ AX, CX
+	SUBQ R9, CX      // windows of the last block searched before
+	CMPQ CX, $16
+	JGE  sse2notfound
+	LEAQ (SI)(R9*1), R11
+	MOVOU (R11), X2
+	PCMPEQB X0, X2
+	MOVOU (R11)(R8*1), X3
+	PCMPEQB X1, X3
+	PAND X2, X3
+	PMOVMSKB X3, BX
+	SHRL CX, BX
+	SHLL CX, BX
+	TESTL BX, BX
+	JZ   sse2notfound
+
+sse2verify:
+	BSFL BX, R12
+	LEAQ (R11)(R12*1), R10 // window
+	MOVQ $1, R13
+
+sse2cmp:
+	CMPQ R13, R8
+	JGE  sse2found
+	MOVB (R10)(R13*1), DX
+	CMPB DX, (DI)(R13*1)
+	JNE  sse2mismatch
+	INCQ R13
+	JMP  sse2cmp
+
+sse2mismatch:
+	LEAL -1(BX), DX
+	ANDL DX, BX
+	JNZ  sse2verify
+	JMP  sse2next
+
+sse2notfound:
+	MOVQ $-1, ret+56(FP)
+	RET
+
+sse2found:
+	SUBQ SI, R10
+	MOVQ R10, ret+56(FP)
+	RET
+
+// func indexAVX2(hay, needle []byte, from int) int
+TEXT ·indexAVX2(SB), NOSPLIT, $0-64
+	MOVQ hay_base+0(FP), SI
+	MOVQ hay_len+8(FP), DX
+	MOVQ needle_base+24(FP), DI
+	MOVQ needle_len+32(FP), R8
+	MOVQ from+48(FP), AX
+
+	// broadcast first and last byte of the needle
+	MOVBQZX (DI), R10
+	MOVQ R10, X0
+	VPBROADCASTB X0, Y0
+	MOVBQZX -1(DI)(R8*1), R10
+	MOVQ R10, X1
+	VPBROADCASTB X1, Y1
+
+	DECQ R8          // k-1: distance first to last byte
+	MOVQ DX, R9
+	SUBQ R8, R9      // n-k+1 windows
+	SUBQ $32, R9     // start of the last block
+	XORQ CX, CX      // CX != 0 in the last block
+
+avx2loop:
+	CMPQ AX, R9
+	JGT  avx2last
+	LEAQ (SI)(AX*1), R11
+	VPCMPEQB (R11), Y0, Y2
+	VPCMPEQB (R11)(R8*1), Y1, Y3
+	VPAND Y2, Y3, Y3
+	VPMOVMSKB Y3, BX
+	TESTL BX, BX
+	JNZ avx2verify
+
+avx2next:
+	TESTQ CX, CX
+	JNZ avx2notfound
+	ADDQ $32, AX
+	JMP avx2loop
+
+avx2last:
+	MOVQ AX, CX
+	SUBQ R9, CX      // windows of the last block searched before
+	CMPQ CX, $32
+	JGE  avx2notfound
+	LEAQ (SI)(R9*1), R11
+	VPCMPEQB (R11), Y0, Y2
+	VPCMPEQB (R11)(R8*1), Y1, Y3
+	VPAND Y2, Y3, Y3
+	VPMOVMSKB Y3, BX
+	SHRL CX, BX
+	SHLL CX, BX
+	TESTL BX, BX
+	JZ   avx2notfound
+
+avx2verify:
+	BSFL BX, R12
+	LEAQ (R11)(R12*1), R10 // window
+	MOVQ $1, R13
+
+avx2cmp:
+	CMPQ R13, R8
+	JGE  avx2found
+	MOVB (R10)(R13*1), DX
+	CMPB DX, (DI)(R13*1)
+	JNE  avx2mismatch
+	INCQ R13
+	JMP  avx2cmp
+
+avx2mismatch:
+	LEAL -1(BX), DX
+	ANDL DX, BX
+	JNZ  avx2verify
+	JMP  avx2next
+
+avx2notfound:
+	VZEROUPPER
+	MOVQ $-1, ret+56(FP)
+	RET
+
+avx2found:
+	VZEROUPPER
+	SUBQ SI, R10
+	MOVQ R10, ret+56(FP)
+	RET
+
+// func indexByteSSE2(hay []byte, c byte) int
+TEXT ·indexByteSSE2(SB), NOSPLIT, $0-40
+	MOVQ hay_base+0(FP), SI
+	MOVQ hay_len+8(FP), DX
+	MOVBQZX c+24(FP), R10
+	MOVQ $0x0101010101010101, R12
+	IMULQ R12, R10
+	MOVQ R10, X0
+	PUNPCKLQDQ X0, X0
+
+	XORQ AX, AX
+	LEAQ -16(DX), R9 // start of the last block
+
+sse2byteloop:
+	CMPQ AX, R9
+	JGT  sse2bytelast
+	MOVOU (SI)(AX*1), X2
+	PCMPEQB X0, X2
+	PMOVMSKB X2, BX
+	TESTL BX, BX
+	JNZ  sse2bytefound
+	ADDQ $16, AX
+	JMP  sse2byteloop
+
+sse2bytelast:
+	MOVQ AX, CX
+	SUBQ R9, CX      // bytes of the last block searched before
+	CMPQ CX, $16
+	JGE  sse2bytenotfound
+	MOVQ R9, AX
+	MOVOU (SI)(AX*1), X2
+	PCMPEQB X0, X2
+	PMOVMSKB X2, BX
+	SHRL CX, BX
+	SHLL CX, BX
+	TESTL BX, BX
+	JZ   sse2bytenotfound
+
+sse2bytefound:
+	BSFL BX, BX
+	ADDQ BX, AX
+	MOVQ AX, ret+32(FP)
+	RET
+
+sse2bytenotfound:
+	MOVQ $-1, ret+32(FP)
+	RET
+
+// func indexByteAVX2(hay []byte, c byte) int
+TEXT ·indexByteAVX2(SB), NOSPLIT, $0-40
+	MOVQ hay_base+0(FP), SI
+	MOVQ hay_len+8(FP), DX
+	MOVBQZX c+24(FP), R10
+	MOVQ R10, X0
+	VPBROADCASTB X0, Y0
+
+	XORQ AX, AX
+	LEAQ -32(DX), R9 // start of the last block
+
+avx2byteloop:
+	CMPQ AX, R9
+	JGT  avx2bytelast
+	VPCMPEQB (SI)(AX*1), Y0, Y2
+	VPMOVMSKB Y2, BX
+	TESTL BX, BX
+	JNZ  avx2bytefound
+	ADDQ $32, AX
+	JMP  avx2byteloop
+
+avx2bytelast:
+	MOVQ AX, CX
+	SUBQ R9, CX      // bytes of the last block searched before
+	CMPQ CX, $32
+	JGE  avx2bytenotfound
+	MOVQ R9, AX
+	VPCMPEQB (SI)(AX*1), Y0, Y2
+	VPMOVMSKB Y2, BX
+	SHRL CX, BX
+	SHLL CX, BX
+	TESTL BX, BX
+	JZ   avx2bytenotfound
+
+avx2bytefound:
+	VZEROUPPER
+	BSFL BX, BX
+	ADDQ BX, AX
+	MOVQ AX, ret+32(FP)
+	RET
+
+avx2bytenotfound:
+	VZEROUPPER
+	MOVQ $-1, ret+32(FP)
+	RET
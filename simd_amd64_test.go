@@ -0,0 +1,118 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build amd64 && !purego
+
+package bmatch
+
+import (
+	"bytes"
+	"testing"
+)
+
+// kernels returns the vector kernels this CPU runs
+func kernels() []simdKernels {
+	ks := []simdKernels{{"sse2", 16, indexSSE2, indexByteSSE2}}
+	if hasAVX2() {
+		ks = append(ks, simdKernels{"avx2", 32, indexAVX2, indexByteAVX2})
+	}
+	return ks
+}
+
+// TestSIMD_Bounds searches haystacks cut from a buffer filled with the needle,
+// so a window read beyond the haystack shows up as a hit
+func TestSIMD_Bounds(t *testing.T) {
+	for _, k := range kernels() {
+		for _, m := range []int{2, 3, 5, 16, 17, 33, 64} {
+			needle := bytes.Repeat([]byte{'x'}, m)
+			buf := bytes.Repeat([]byte{'x'}, 3*k.width+2*m)
+			for n := m + k.width - 1; n < m+3*k.width; n++ {
+				hay := buf[m : m+n : m+n]
+				for i := range hay {
+					hay[i] = '.'
+				}
+				for from := 0; from <= n; from++ {
+					if got := k.index(hay, needle, from); got != -1 {
+						t.Fatalf("%s m %d n %d from %d: index %d on miss", k.name, m, n, from, got)
+					}
+				}
+				for p := 0; p+m <= n; p++ {
+					copy(hay[p:], needle)
+					for _, from := range []int{0, p, p + 1} {
+						want := -1
+						if from <= p {
+							want = p
+						}
+						if got := k.index(hay, needle, from); got != want {
+							t.Fatalf("%s m %d n %d from %d: index %d, want %d", k.name, m, n, from, got, want)
+						}
+					}
+					for i := p; i < p+m; i++ {
+						hay[i] = '.'
+					}
+				}
+				for i := range hay {
+					hay[i] = 'x'
+				}
+			}
+			for n := k.width; n < 3*k.width; n++ {
+				hay := buf[m : m+n : m+n]
+				for i := range hay {
+					hay[i] = '.'
+				}
+				if got := k.indexByte(hay, 'x'); got != -1 {
+					t.Fatalf("%s n %d: indexByte %d on miss", k.name, n, got)
+				}
+				for p := range hay {
+					hay[p] = 'x'
+					if got := k.indexByte(hay, 'x'); got != p {
+						t.Fatalf("%s n %d: indexByte %d, want %d", k.name, n, got, p)
+					}
+					hay[p] = '.'
+				}
+				for i := range hay {
+					hay[i] = 'x'
+				}
+			}
+		}
+	}
+}
+
+// TestSIMD_Candidates checks needles whose first and last byte are frequent in the haystack
+func TestSIMD_Candidates(t *testing.T) {
+	hay := bytes.Repeat([]byte("abaabaaab"), 40)
+	for _, k := range kernels() {
+		for m := 2; m <= 20; m++ {
+			needle := append(append([]byte{'a'}, bytes.Repeat([]byte{'b'}, m-2)...), 'a')
+			needle[m/2] = 'a'
+			for from := 0; from < len(hay); from++ {
+				want := bytes.Index(hay[from:], needle)
+				if want >= 0 {
+					want += from
+				}
+				if got := k.index(hay, needle, from); got != want {
+					t.Fatalf("%s needle %q from %d: index %d, want %d", k.name, needle, from, got, want)
+				}
+			}
+		}
+	}
+}
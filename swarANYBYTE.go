@@ -0,0 +1,170 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * Byte set scanning on the SWAR machinery of swarMEMCHR.go:
+ * for sets of up to anyByteMasks bytes each word of the haystack is compared with every
+ * byte of the set broadcast to a word, and the zeroBytes marks are ORed (negated for
+ * IndexNotByte). Larger sets are looked up byte by byte in a 256 bit table.
+ */
+
+package bmatch
+
+import (
+	"math/bits"
+)
+
+// anyByteMasks is the largest set searched with broadcast masks
+const anyByteMasks = 8
+
+const hi8 = 0x8080808080808080
+
+// byteSet is the set of bytes searched by IndexAnyByte and IndexNotByte
+type byteSet struct {
+	masks []uint64 // broadcast set bytes; nil for sets over anyByteMasks bytes
+	table [4]uint64
+	not   bool // search bytes not in the set
+}
+
+func newByteSet(set []byte, not bool) *byteSet {
+	s := &byteSet{not: not}
+	for _, c := range set {
+		if !s.has(c) {
+			s.table[c>>6] |= 1 << (c & 63)
+			s.masks = append(s.masks, ones*uint64(c))
+		}
+	}
+	if len(s.masks) > anyByteMasks {
+		s.masks = nil
+	}
+	return s
+}
+
+// has reports whether c is in the set
+func (s *byteSet) has(c byte) bool {
+	return s.table[c>>6]&(1<<(c&63)) != 0
+}
+
+// match reports whether c is searched
+func (s *byteSet) match(c byte) bool {
+	return s.has(c) != s.not
+}
+
+// scan reports the searched bytes of hay in ascending order to report, until it returns true
+func (s *byteSet) scan(hay []byte, report func(int) bool) {
+	var (
+		n = len(hay)
+		i int
+	)
+	if s.masks != nil {
+		for ; i+8 <= n; i += 8 {
+			for z := s.marks(loadWord(hay, i)); z != 0; z = clearFirst(z) {
+				if report(i + firstByte(z)) {
+					return
+				}
+			}
+		}
+	}
+	for ; i < n; i++ {
+		if s.match(hay[i]) && report(i) {
+			return
+		}
+	}
+}
+
+// count returns the number of searched bytes in hay
+func (s *byteSet) count(hay []byte) (count int) {
+	var (
+		n = len(hay)
+		i int
+	)
+	if s.masks != nil {
+		for ; i+8 <= n; i += 8 {
+			count += bits.OnesCount64(s.marks(loadWord(hay, i)))
+		}
+	}
+	for ; i < n; i++ {
+		if s.match(hay[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+// marks returns the high bit of each searched byte of the word w
+func (s *byteSet) marks(w uint64) (z uint64) {
+	for _, mask := range s.masks {
+		z |= zeroBytes(w ^ mask)
+	}
+	if s.not {
+		z ^= hi8
+	}
+	return z
+}
+
+func (s *byteSet) index(hay []byte) (idx int) {
+	idx = -1
+	s.scan(hay, func(i int) bool {
+		idx = i
+		return true
+	})
+	return idx
+}
+
+func (s *byteSet) findAll(hay []byte) (found []int) {
+	s.scan(hay, func(i int) bool {
+		found = append(found, i)
+		return false
+	})
+	return found
+}
+
+// IndexAnyByte returns the index of the first byte of haystack that is in set, or -1.
+func IndexAnyByte(haystack, set *[]byte) int {
+	return newByteSet(*set, false).index(*haystack)
+}
+
+// CountAnyByte returns the number of bytes of haystack that are in set.
+func CountAnyByte(haystack, set *[]byte) int {
+	return newByteSet(*set, false).count(*haystack)
+}
+
+// FindAllAnyByte returns the indices of all bytes of haystack that are in set.
+func FindAllAnyByte(haystack, set *[]byte) []int {
+	return newByteSet(*set, false).findAll(*haystack)
+}
+
+// IndexNotByte returns the index of the first byte of haystack that is not in set, or -1;
+// i.e. with set = []byte(" \t") IndexNotByte(&line, &set) skips the indentation.
+func IndexNotByte(haystack, set *[]byte) int {
+	return newByteSet(*set, true).index(*haystack)
+}
+
+// CountNotByte returns the number of bytes of haystack that are not in set.
+func CountNotByte(haystack, set *[]byte) int {
+	return newByteSet(*set, true).count(*haystack)
+}
+
+// FindAllNotByte returns the indices of all bytes of haystack that are not in set.
+func FindAllNotByte(haystack, set *[]byte) []int {
+	return newByteSet(*set, true).findAll(*haystack)
+}
@@ -0,0 +1,122 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+// anyByteRef returns the indices of the bytes of hay in set (not in set)
+func anyByteRef(hay, set []byte, not bool) (found []int) {
+	for i, c := range hay {
+		if (bytes.IndexByte(set, c) >= 0) != not {
+			found = append(found, i)
+		}
+	}
+	return found
+}
+
+func TestAnyByte(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	text := testcorpus.Text(1, 4096)
+	for _, size := range []int{0, 1, 3, anyByteMasks, anyByteMasks + 1, 40, 256} {
+		set := make([]byte, size)
+		for k := range set {
+			set[k] = byte(rnd.Intn(256))
+			if k%2 == 0 {
+				set[k] = text[rnd.Intn(len(text))]
+			}
+		}
+		if size == 256 {
+			for k := range set {
+				set[k] = byte(k)
+			}
+		}
+		for n := 0; n < 40; n++ {
+			for _, off := range []int{0, 1, 7, 1000} {
+				hay := text[off : off+n]
+				for _, not := range []bool{false, true} {
+					want := anyByteRef(hay, set, not)
+					var (
+						found []int
+						count int
+						idx   int
+					)
+					if not {
+						found, count, idx = FindAllNotByte(&hay, &set), CountNotByte(&hay, &set), IndexNotByte(&hay, &set)
+					} else {
+						found, count, idx = FindAllAnyByte(&hay, &set), CountAnyByte(&hay, &set), IndexAnyByte(&hay, &set)
+					}
+					wantIdx := -1
+					if len(want) > 0 {
+						wantIdx = want[0]
+					}
+					if idx != wantIdx || count != len(want) || len(found) != len(want) {
+						t.Fatalf("set %q not %v hay %q: index %d count %d found %v; want %v", set, not, hay, idx, count, found, want)
+					}
+					for k := range found {
+						if found[k] != want[k] {
+							t.Fatalf("set %q not %v hay %q: found %v; want %v", set, not, hay, found, want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestIndexNotByte_Indentation(t *testing.T) {
+	line := []byte(" \t \t\t    func main() {")
+	set := []byte(" \t")
+	if i := IndexNotByte(&line, &set); i != 9 {
+		t.Errorf("IndexNotByte = %d; want 9", i)
+	}
+	blank := []byte("  \t  \t  \t  ")
+	if i := IndexNotByte(&blank, &set); i != -1 {
+		t.Errorf("IndexNotByte on blank line = %d; want -1", i)
+	}
+}
+
+// BenchmarkAnyByte times IndexAnyByte for three delimiters (broadcast masks)
+// and for a set of 20 bytes (table), both absent from the haystack
+func BenchmarkAnyByte(b *testing.B) {
+	hay := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz"), 1<<15)
+	for _, c := range []struct {
+		name string
+		set  []byte
+	}{
+		{"masks", []byte(",;\n")},
+		{"table", []byte(",;\n0123456789!?%&/()=")},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			b.SetBytes(int64(len(hay)))
+			for i := 0; i < b.N; i++ {
+				IndexAnyByte(&hay, &c.set)
+			}
+		})
+	}
+}
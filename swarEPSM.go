@@ -0,0 +1,149 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * 'nos esse quasi nanos gigantum umeris insidentes' (Bernhard von Chartres, 1120)
+ * The giants in this respect:
+ * This is a SWAR variant of the Exact Packed String Matching algorithm published by
+ * S. Faro and M. O. Külekci (2013):
+ * Fast Packed String Matching for Short Patterns.
+ * Proceedings of the 15th Meeting on Algorithm Engineering and Experiments (ALENEX 2013), pp.113--121.
+ *
+ * For each needle byte k the word at i+k is compared with the byte broadcast to all eight
+ * bytes (zeroBytes, see swarMEMCHR.go); ANDing the results of all needle bytes marks the
+ * windows i..i+7 the needle matches exactly. First and last needle byte are checked first;
+ * the inner bytes only if both matched somewhere in the word. No verification is needed.
+ */
+
+package bmatch
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/AndreasBriese/bmatch/bs_fsbndm"
+	"github.com/AndreasBriese/bmatch/registry"
+)
+
+// Errors
+var (
+	EPSMLONG = errors.New("length of needle is larger 8")
+)
+
+// epsmMaxNeedle is the longest needle searched by epsm:
+// each needle byte costs a word operation per eight windows
+const epsmMaxNeedle = 8
+
+// epsmSearch reports the windows of hay matching needle (2 <= len(needle) <= len(hay)) to report,
+// in ascending order, until report returns true
+func epsmSearch(hay, needle []byte, report func(int) bool) {
+
+	var (
+		n     = len(hay)
+		m     = len(needle)
+		mm1   = m - 1
+		masks [epsmMaxNeedle]uint64
+		i     int
+	)
+
+	for k, c := range needle {
+		masks[k] = ones * uint64(c)
+	}
+
+	// words at i..i+mm1 are read: all within hay while i+mm1+8 <= n
+	for ; i+mm1+8 <= n; i += 8 {
+		z := zeroBytes(loadWord(hay, i)^masks[0]) & zeroBytes(loadWord(hay, i+mm1)^masks[mm1])
+		for k := 1; k < mm1 && z != 0; k++ {
+			z &= zeroBytes(loadWord(hay, i+k) ^ masks[k])
+		}
+		for ; z != 0; z = clearFirst(z) {
+			if report(i + firstByte(z)) {
+				return
+			}
+		}
+	}
+	for ; i+m <= n; i++ {
+		if hay[i] == needle[0] && bytes.Equal(hay[i+1:i+m], needle[1:]) && report(i) {
+			return
+		}
+	}
+}
+
+// epsm implements registry.Searcher for needles of 2 to epsmMaxNeedle bytes
+type epsm struct{}
+
+func (epsm) Index(haystack, needle *[]byte) (idx int, e error) {
+	if e = epsmCheck(haystack, needle); e != nil {
+		return -1, e
+	}
+	idx = -1
+	epsmSearch(*haystack, *needle, func(i int) bool {
+		idx = i
+		return true
+	})
+	return idx, nil
+}
+
+func (epsm) Count(haystack, needle *[]byte) (count int, e error) {
+	if e = epsmCheck(haystack, needle); e != nil {
+		return -1, e
+	}
+	epsmSearch(*haystack, *needle, func(int) bool {
+		count++
+		return false
+	})
+	return count, nil
+}
+
+func (epsm) FindAll(haystack, needle *[]byte) (found []int, e error) {
+	if e = epsmCheck(haystack, needle); e != nil {
+		return found, e
+	}
+	epsmSearch(*haystack, *needle, func(i int) bool {
+		found = append(found, i)
+		return false
+	})
+	return found, nil
+}
+
+// epsmCheck returns bs_fsbndm's errors for the same needles, EPSMLONG for needles over 8 bytes
+func epsmCheck(haystack, needle *[]byte) error {
+	switch {
+	case len(*needle) > epsmMaxNeedle:
+		return EPSMLONG
+	case len(*haystack) < len(*needle):
+		return bs_fsbndm.NEEDLELONG
+	case len(*needle) < 2:
+		return bs_fsbndm.NEEDLESHORT
+	}
+	return nil
+}
+
+// epsmAlgorithm is registered by the init() in dispatch.go
+var epsmAlgorithm = Algorithm{
+	Name:      "epsm",
+	MinNeedle: 2,
+	MaxNeedle: epsmMaxNeedle,
+	WorstCase: registry.Linear,
+	Alphabet:  registry.AnyAlphabet,
+	Searcher:  epsm{},
+}
@@ -0,0 +1,77 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bmatch
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/AndreasBriese/bmatch/internal/testcorpus"
+)
+
+func TestCorpora_EPSM(t *testing.T) {
+	testcorpus.Check(t, epsm{}, 2, epsmMaxNeedle)
+}
+
+func TestEPSM_Limits(t *testing.T) {
+	hay := []byte("abcdefghijklmnop")
+	for _, needle := range [][]byte{[]byte("a"), []byte("abcdefghi")} {
+		if _, e := (epsm{}).Index(&hay, &needle); e == nil {
+			t.Errorf("needle %q: no error", needle)
+		}
+	}
+}
+
+// BenchmarkEPSM counts needles of 2 to 8 bytes with epsm, bs_fsbndm and simd:
+// go test -run XX -bench EPSM
+func BenchmarkEPSM(b *testing.B) {
+	for _, c := range []struct {
+		name string
+		data []byte
+	}{
+		{"text", testcorpus.Text(1, 1<<20)},
+		{"dna", testcorpus.DNA(1, 1<<20)},
+		{"binary", testcorpus.Binary(1, 1<<20)},
+	} {
+		for m := 2; m <= epsmMaxNeedle; m++ {
+			needles := testcorpus.Needles(int64(m), c.data, m, 16)
+			for _, s := range []struct {
+				name string
+				Searcher
+			}{
+				{"epsm", epsm{}},
+				{"bs_fsbndm", bsfSearcher{}},
+				{"simd", simdSearcher{}},
+			} {
+				b.Run(c.name+"/"+strconv.Itoa(m)+"/"+s.name, func(b *testing.B) {
+					b.SetBytes(int64(len(c.data) * len(needles)))
+					for i := 0; i < b.N; i++ {
+						for k := range needles {
+							s.Count(&c.data, &needles[k])
+						}
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// go package bmatch
+//
+// The MIT License (MIT)
+// Copyright (c) 2016 Andreas Briese, eduToolbox@Bri-C GmbH, Sarstedt
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+/*
+ * 'nos esse quasi nanos gigantum umeris insidentes' (Bernhard von Chartres, 1120)
+ * The giants in this respect:
+ * func memchr(haystack, patttern)
+ * This native Go 64-bit algorithm derives
+ * from insights into the 32bit implementation in c at
+ * http://www.stdlib.net/~colmmacc/strlen.c.html
+ * Copyright (C) 1991, 1993, 1997, 2000, 2003 Free Software Foundation, Inc.
+ * part of the GNU C Library.
+ *    Written by Torbjorn Granlund (tege@sics.se),
+ *    with help from Dan Sahlin (dan@sics.se);
+ *    commentary by Jim Blandy (jimb@ai.mit.edu).
+ *
+ * The haystack is read in 64 bit words; each word is XORed with the needle byte broadcast
+ * to all eight bytes, so matching bytes become zero, and zeroBytes marks them exactly.
+ * Words are only read where all eight bytes lie within the haystack, the rest is compared
+ * byte by byte. loadWord and the bit scan (firstByte, clearFirst) come from
+ * swarMEMCHR_le.go or swarMEMCHR_be.go (unsafe native loads) or swarMEMCHR_purego.go
+ * (encoding/binary, for the purego build tag and all other architectures).
+ */
+
+package bmatch
+
+import (
+	"math/bits"
+)
+
+const (
+	lo7  = 0x7f7f7f7f7f7f7f7f
+	ones = 0x0101010101010101
+)
+
+// zeroBytes returns a word with the high bit set in each zero byte of x and all other bits clear.
+// Other than (x - ones) & ^x & hi7 no borrow runs from a zero byte into the next one,
+// so every bit marks a zero byte: they may be counted and scanned from either end
+func zeroBytes(x uint64) uint64 {
+	return ^((x&lo7 + lo7) | x | lo7)
+}
+
+// firstByteLE returns the index of the first marked byte of a little endian word
+func firstByteLE(z uint64) int {
+	return bits.TrailingZeros64(z) >> 3
+}
+
+// firstByteBE returns the index of the first marked byte of a big endian word
+func firstByteBE(z uint64) int {
+	return bits.LeadingZeros64(z) >> 3
+}
+
+// clearFirstLE clears the first marked byte of a little endian word
+func clearFirstLE(z uint64) uint64 {
+	return z & (z - 1)
+}
+
+// clearFirstBE clears the first marked byte of a big endian word
+func clearFirstBE(z uint64) uint64 {
+	return z &^ (1 << 63 >> uint(bits.LeadingZeros64(z)))
+}
+
+/*
+ * func mmIndex(haystack, needle *[]byte)
+ * returns first index of the needle's (last) byte in haystack
+ */
+func mmIndex(haystack, needle *[]byte) int {
+
+	var (
+		pat   = *needle
+		char  = pat[len(pat)-1]
+		hayst = *haystack
+		n     = len(hayst)
+		mask  = ones * uint64(char)
+		i     int
+	)
+
+	if n < len(pat) {
+		return -1
+	}
+
+	for ; i+8 <= n; i += 8 {
+		if z := zeroBytes(loadWord(hayst, i) ^ mask); z != 0 {
+			return i + firstByte(z)
+		}
+	}
+	for ; i < n; i++ {
+		if hayst[i] == char {
+			return i
+		}
+	}
+
+	return -1
+}
+
+/*
+ * func mmFindALL(haystack, needle *[]byte)
+ * returns []int containing all indices of the needle's (last) byte in haystack
+ */
+func mmFindALL(haystack, needle *[]byte) (found []int) {
+
+	var (
+		pat   = *needle
+		char  = pat[len(pat)-1]
+		hayst = *haystack
+		n     = len(hayst)
+		mask  = ones * uint64(char)
+		i     int
+	)
+
+	if n < len(pat) {
+		return found
+	}
+
+	buflen := 10 + n>>8
+	found = make([]int, 0, buflen)
+
+	for ; i+8 <= n; i += 8 {
+		for z := zeroBytes(loadWord(hayst, i) ^ mask); z != 0; z = clearFirst(z) {
+			found = append(found, i+firstByte(z))
+		}
+	}
+	for ; i < n; i++ {
+		if hayst[i] == char {
+			found = append(found, i)
+		}
+	}
+
+	return found
+}
+
+/*
+ * func mmCount(haystack, needle *[]byte)
+ * returns the total number of the needle's (last) byte in haystack
+ */
+func mmCount(haystack, needle *[]byte) (count int) {
+
+	var (
+		pat   = *needle
+		char  = pat[len(pat)-1]
+		hayst = *haystack
+		n     = len(hayst)
+		mask  = ones * uint64(char)
+		i     int
+	)
+
+	if n < len(pat) {
+		return count
+	}
+
+	for ; i+8 <= n; i += 8 {
+		count += bits.OnesCount64(zeroBytes(loadWord(hayst, i) ^ mask))
+	}
+	for ; i < n; i++ {
+		if hayst[i] == char {
+			count++
+		}
+	}
+
+	return count
+}